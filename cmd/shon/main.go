@@ -8,6 +8,7 @@ package main
 
 import (
 	"encoding/json"
+	"errors"
 	"io"
 	"log"
 	"os"
@@ -25,7 +26,7 @@ func main() {
 func run(stdout io.Writer, args []string) error {
 	var x any
 	if err := shon.Parse(args, &x); err != nil {
-		return err
+		return errors.New(shon.FormatError(args, err))
 	}
 
 	enc := json.NewEncoder(stdout)
@@ -26,4 +26,13 @@ func TestRun(t *testing.T) {
 		err := run(io.Discard, []string{"]"})
 		assert.ErrorContains(t, err, "expected a value")
 	})
+
+	t.Run("failure position", func(t *testing.T) {
+		t.Parallel()
+
+		err := run(io.Discard, []string{"[", "a", "-x", "]"})
+		assert.EqualError(t, err, `argument 2: unexpected flag "-x"; did you mean "-- -x"?
+  [ a -x ]
+      ^~`)
+	})
 }
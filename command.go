@@ -0,0 +1,226 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// ParseCommand decodes args into a tree of commands rooted at v,
+// and reports the names of the subcommands that were selected, in order.
+// v must be a pointer to a struct.
+//
+// Subcommands are struct fields, or pointers to struct fields,
+// tagged with the cmd option:
+//
+//	type Root struct {
+//		Verbose bool      `shon:"verbose"`
+//		Build   *BuildCmd `shon:"build,cmd"`
+//		Test    *TestCmd  `shon:"test,cmd"`
+//	}
+//
+//	type BuildCmd struct {
+//		Target string `shon:"target"`
+//	}
+//
+// Arguments up to the first positional argument are decoded into v
+// like with [ParseObjectPrefix].
+// That argument must be the name of a subcommand of v,
+// and the arguments after it are decoded into that subcommand
+// in the same way, recursively.
+// The arguments for a command without subcommands
+// are decoded like with [ParseObject],
+// so only such commands may have positional argument fields.
+//
+// For example, the following selects the "build" command above,
+// setting Root.Verbose and Root.Build.Target:
+//
+//	--verbose -t build --target foo
+//
+// ParseCommand returns []string{"build"} for this input.
+// Pointer fields are allocated only for the selected subcommands.
+// If the arguments end before a subcommand is named,
+// the returned path ends at the last command that was decoded,
+// and is empty if that's v itself.
+//
+// If a command asks for help, ParseCommand returns [ErrHelp]
+// along with the path to that command.
+//
+// ParseCommand reports a [*DecodeError] if a subcommand is unknown.
+// Errors for arguments of subcommands are reported with paths
+// relative to v, e.g. ".build.target".
+func ParseCommand(args []string, v any, opts ...ParseOption) (path []string, err error) {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer || dst.Elem().Kind() != reflect.Struct {
+		return nil, errors.New("must be a pointer to a struct")
+	}
+
+	options := buildParseOptions(opts...)
+	ctx := options.decodeCtx(args)
+
+	var errs []error
+	if options.allErrors {
+		ctx.Errs = &errs
+	}
+
+	// Commands that were decoded, starting at the root.
+	type level struct {
+		v   reflect.Value
+		sub *subcommand // selected subcommand, if any
+	}
+
+	var (
+		levels []level
+		into   = dst.Elem()
+		start  int
+	)
+	for t := into.Type(); ; {
+		cmd, err := newCommand(t)
+		if err != nil {
+			return nil, err
+		}
+
+		readFn := (*parser).object
+		if len(cmd.subs) > 0 {
+			readFn = (*parser).objectPrefix
+		}
+
+		res, end, err := decodePrefix(ctx.into(into), start, readFn, cmd.dec)
+		if errors.Is(err, ErrHelp) {
+			return path, ErrHelp
+		}
+		if err != nil {
+			errs = append(errs, err)
+			break
+		}
+
+		levels = append(levels, level{v: res})
+		if end >= len(args) {
+			// The object of a command without subcommands
+			// always reaches the end.
+			break
+		}
+
+		name := args[end]
+		sub, ok := cmd.subsByName[name]
+		if !ok {
+			err := ctx.newError(t, value{t: scalarType, s: name, pos: end}, fmt.Errorf("unknown command %q", name))
+			err.Suggestions = suggest(name, cmd.names())
+			errs = append(errs, err)
+			break
+		}
+
+		levels[len(levels)-1].sub = sub
+		path = append(path, sub.names[0])
+		ctx = ctx.withKey(sub.names[0])
+		start = end + 1
+
+		t = sub.t
+		into = fieldByIndex(res, sub.index, false)
+		if into.IsValid() && into.Kind() == reflect.Pointer {
+			if into.IsNil() {
+				into = reflect.Value{}
+			} else {
+				into = into.Elem()
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return nil, joinErrors(errs)
+	}
+
+	ctx.Writes.commit()
+
+	// Place each subcommand into its parent.
+	for i := len(levels) - 2; i >= 0; i-- {
+		parent, child := levels[i], levels[i+1].v
+		f := fieldByIndex(parent.v, parent.sub.index, true)
+		if f.Kind() == reflect.Pointer {
+			if options.merge && !f.IsNil() {
+				f.Elem().Set(child)
+				continue
+			}
+
+			ptr := reflect.New(child.Type())
+			ptr.Elem().Set(child)
+			child = ptr
+		}
+		f.Set(child)
+	}
+
+	dst.Elem().Set(levels[0].v)
+	return path, nil
+}
+
+// command is a struct that may have subcommands.
+type command struct {
+	dec decoder // decoder for the command's own fields
+
+	subs       []*subcommand // in declaration order
+	subsByName map[string]*subcommand
+}
+
+type subcommand struct {
+	t     reflect.Type // struct type of the subcommand
+	index []int        // see fieldInfo.index
+
+	// List of names this subcommand accepts.
+	// See fieldTag.names.
+	names []string
+}
+
+func newCommand(t reflect.Type) (*command, error) {
+	dec, err := newDecoder(t)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, err := visibleFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	c := command{
+		dec:        dec,
+		subsByName: make(map[string]*subcommand),
+	}
+	for _, info := range infos {
+		if !info.tag.cmd {
+			continue
+		}
+
+		st := info.field.Type
+		if st.Kind() == reflect.Pointer {
+			st = st.Elem()
+		}
+		sub := &subcommand{t: st, index: info.index, names: info.names}
+		c.subs = append(c.subs, sub)
+		c.subsByName[sub.names[0]] = sub
+	}
+
+	// Canonical names take precedence over other names.
+	for _, sub := range c.subs {
+		for _, name := range sub.names[1:] {
+			if _, ok := c.subsByName[name]; !ok {
+				c.subsByName[name] = sub
+			}
+		}
+	}
+
+	if sd, ok := dec.(*structDecoder); ok && len(c.subs) > 0 {
+		if len(sd.args) > 0 || sd.argsRest >= 0 {
+			return nil, fmt.Errorf("%v: commands with subcommands cannot have positional arguments", t)
+		}
+	}
+
+	return &c, nil
+}
+
+// names returns the canonical names of all subcommands.
+func (c *command) names() []string {
+	names := make([]string, len(c.subs))
+	for i, sub := range c.subs {
+		names[i] = sub.names[0]
+	}
+	return names
+}
@@ -0,0 +1,286 @@
+package shon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRemoteAddCmd struct {
+	Fetch bool   `shon:"fetch"`
+	Name  string `shon:"name,arg,required"`
+	URL   string `shon:"url,arg,required"`
+}
+
+type testRemoteCmd struct {
+	Verbose bool              `shon:"verbose"`
+	Add     *testRemoteAddCmd `shon:"add,cmd"`
+	Remove  *struct {
+		Name string `shon:"name,arg"`
+	} `shon:"remove,cmd"`
+}
+
+type testBuildCmd struct {
+	Target string   `shon:"target" default:"all"`
+	Files  []string `shon:"files,args"`
+}
+
+type testRootCmd struct {
+	Verbose bool           `shon:"verbose"`
+	Build   *testBuildCmd  `shon:"build,cmd"`
+	Remote  testRemoteCmd  `shon:"remote,cmd"`
+	Config  map[string]any `shon:"config"`
+}
+
+func TestParseCommand(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		give     []string
+		want     testRootCmd
+		wantPath []string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "root options",
+			give: []string{"--verbose", "-t"},
+			want: testRootCmd{Verbose: true},
+		},
+		{
+			desc: "subcommand",
+			give: []string{"--verbose", "-t", "build", "--target", "foo", "a.go", "--", "-b.go"},
+			want: testRootCmd{
+				Verbose: true,
+				Build: &testBuildCmd{
+					Target: "foo",
+					Files:  []string{"a.go", "-b.go"},
+				},
+			},
+			wantPath: []string{"build"},
+		},
+		{
+			desc: "subcommand defaults",
+			give: []string{"build"},
+			want: testRootCmd{
+				Build: &testBuildCmd{Target: "all"},
+			},
+			wantPath: []string{"build"},
+		},
+		{
+			desc: "nested",
+			give: []string{
+				"--config", "[", "--x", "1", "]",
+				"remote", "--verbose", "-t",
+				"add", "origin", "--fetch", "-t", "https://example.com",
+			},
+			want: testRootCmd{
+				Config: map[string]any{"x": 1},
+				Remote: testRemoteCmd{
+					Verbose: true,
+					Add: &testRemoteAddCmd{
+						Fetch: true,
+						Name:  "origin",
+						URL:   "https://example.com",
+					},
+				},
+			},
+			wantPath: []string{"remote", "add"},
+		},
+		{
+			desc:     "no leaf",
+			give:     []string{"remote", "--verbose", "-t"},
+			want:     testRootCmd{Remote: testRemoteCmd{Verbose: true}},
+			wantPath: []string{"remote"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got testRootCmd
+			path, err := ParseCommand(tt.give, &got)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestParseCommand_merge(t *testing.T) {
+	t.Parallel()
+
+	got := testRootCmd{
+		Verbose: true,
+		Build:   &testBuildCmd{Target: "old", Files: []string{"a"}},
+	}
+	old := got.Build
+
+	path, err := ParseCommand([]string{"build", "b"}, &got, Merge(true), AppendSlices(true))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"build"}, path)
+	assert.Equal(t, testRootCmd{
+		Verbose: true,
+		Build:   &testBuildCmd{Target: "old", Files: []string{"a", "b"}},
+	}, got)
+	assert.Same(t, old, got.Build, "existing subcommand must be decoded into")
+}
+
+func TestParseCommand_mergeError(t *testing.T) {
+	t.Parallel()
+
+	got := testRootCmd{
+		Build: &testBuildCmd{Target: "old", Files: []string{"a"}},
+	}
+	_, err := ParseCommand([]string{"--verbose", "-t", "build", "--target", "new", "--bogus", "1"}, &got, Merge(true))
+	require.Error(t, err)
+	assert.Equal(t, testRootCmd{
+		Build: &testBuildCmd{Target: "old", Files: []string{"a"}},
+	}, got)
+}
+
+func TestParseCommand_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    []string
+		opts    []ParseOption
+		wantErr string
+	}{
+		{
+			desc:    "unknown command",
+			give:    []string{"--verbose", "-t", "bild"},
+			wantErr: `argument 2: unknown command "bild"; did you mean "build"?`,
+		},
+		{
+			desc:    "unknown nested command",
+			give:    []string{"remote", "ad"},
+			wantErr: `argument 1 (.remote): unknown command "ad"; did you mean "add"?`,
+		},
+		{
+			desc:    "terminator",
+			give:    []string{"--", "build"},
+			wantErr: `argument 0: unknown command "--"`,
+		},
+		{
+			desc:    "bad root option",
+			give:    []string{"--verbose", "x", "build"},
+			wantErr: `argument 1 (.verbose): expected bool, got scalar`,
+		},
+		{
+			desc:    "bad subcommand option",
+			give:    []string{"remote", "add", "--fetch", "x"},
+			wantErr: `argument 3 (.remote.add.fetch): expected bool, got scalar`,
+		},
+		{
+			desc:    "missing argument",
+			give:    []string{"remote", "add", "origin"},
+			wantErr: `argument 2 (.remote.add): missing required field .remote.add.url`,
+		},
+		{
+			desc:    "unknown option",
+			give:    []string{"build", "--targte", "x"},
+			wantErr: `argument 1 (.build.targte): unknown field "targte"; did you mean "target"?`,
+		},
+		{
+			desc: "all errors",
+			give: []string{"--verbose", "x", "remote", "--verbose", "y", "rm"},
+			opts: []ParseOption{AllErrors(true)},
+			wantErr: `argument 1 (.verbose): expected bool, got scalar` + "\n" +
+				`argument 4 (.remote.verbose): expected bool, got scalar` + "\n" +
+				`argument 5 (.remote): unknown command "rm"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := testRootCmd{Verbose: true}
+			path, err := ParseCommand(tt.give, &got, tt.opts...)
+			assert.EqualError(t, err, tt.wantErr)
+			assert.Nil(t, path)
+			assert.Equal(t, testRootCmd{Verbose: true}, got, "must not be modified")
+		})
+	}
+}
+
+func TestParseCommand_help(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		give     []string
+		wantPath []string
+	}{
+		{desc: "root", give: []string{"--verbose", "-t", "-h"}},
+		{desc: "subcommand", give: []string{"remote", "--help", "add"}, wantPath: []string{"remote"}},
+		{desc: "leaf", give: []string{"remote", "add", "origin", "-h"}, wantPath: []string{"remote", "add"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got testRootCmd
+			path, err := ParseCommand(tt.give, &got)
+			assert.Equal(t, ErrHelp, err)
+			assert.Equal(t, tt.wantPath, path)
+		})
+	}
+}
+
+func TestParseCommand_badType(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not a pointer", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseCommand(nil, testRootCmd{})
+		assert.EqualError(t, err, "must be a pointer to a struct")
+	})
+
+	t.Run("not a struct", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := ParseCommand(nil, new(map[string]any))
+		assert.EqualError(t, err, "must be a pointer to a struct")
+	})
+
+	t.Run("not a struct command", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Build string `shon:"build,cmd"`
+		}
+		_, err := ParseCommand(nil, &got)
+		assert.EqualError(t, err, "field Build: cmd requires a struct or pointer to struct, got string")
+	})
+
+	t.Run("positional arguments", func(t *testing.T) {
+		t.Parallel()
+
+		type root struct {
+			File  string        `shon:"file,arg"`
+			Build *testBuildCmd `shon:"build,cmd"`
+		}
+		_, err := ParseCommand([]string{"build"}, new(root))
+		assert.ErrorContains(t, err, "commands with subcommands cannot have positional arguments")
+	})
+}
+
+func TestParseObject_ignoresCommands(t *testing.T) {
+	t.Parallel()
+
+	var got testRootCmd
+	err := ParseObject([]string{"--build", "[", "--target", "x", "]"}, &got)
+	assert.EqualError(t, err, `argument 0 (.build): unknown field "build"`)
+}
@@ -0,0 +1,328 @@
+package shon
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Candidate is a possible value for the argument being completed.
+type Candidate struct {
+	// Value is the complete argument.
+	Value string
+
+	// Description is a one line description of the candidate, if any.
+	// For object keys, this is the first line
+	// of the help:".." struct tag of the field.
+	Description string
+}
+
+// Complete reports candidates for the last argument in args
+// when args are parsed into v with [ParseObject].
+// The last argument may be incomplete or empty,
+// and only candidates that start with it are reported.
+// v must be a struct, a map, or a pointer to one of these.
+//
+// Complete parses the arguments before the last one,
+// tolerating errors in them,
+// to find the object or array that the last argument belongs to.
+// It then reports candidates based on the type of that value:
+//
+//   - for objects: keys of the struct that haven't been used yet,
+//     and ']' if the object is nested
+//   - for values: '-t' and '-f' for bools, '-n' for pointers,
+//     slices and maps, the values listed in enum:".." struct tags,
+//     and '[' for arrays and objects
+//   - for arrays: the values accepted by its items, and ']'
+//
+// Complete returns nil if there are no candidates
+// or if v is not a supported type.
+//
+// Programs can expose this to their shell completion scripts
+// with a hidden subcommand, e.g.:
+//
+//	if len(os.Args) > 1 && os.Args[1] == "__complete" {
+//		for _, c := range shon.Complete(os.Args[2:], &opts) {
+//			fmt.Println(c.Value)
+//		}
+//		return
+//	}
+func Complete(args []string, v any) []Candidate {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || (t.Kind() != reflect.Struct && t.Kind() != reflect.Map) {
+		return nil
+	}
+
+	dec, err := newDecoder(t)
+	if err != nil {
+		return nil
+	}
+
+	var cur string
+	if len(args) > 0 {
+		args, cur = args[:len(args)-1], args[len(args)-1]
+	}
+
+	c := completer{p: &parser{cursor: &sliceCursor{args: args}}}
+	v0, _ := c.p.object()
+	c.object(dec, v0.i.(*cursorObjectReader))
+
+	var cands []Candidate
+	for _, cand := range c.cands {
+		if strings.HasPrefix(cand.Value, cur) {
+			cands = append(cands, cand)
+		}
+	}
+	return cands
+}
+
+// completer walks the input with the parser
+// alongside the decoders for the values in it
+// until it reaches the end of the input.
+type completer struct {
+	p *parser
+
+	// Candidates for the argument after the input.
+	// Set once the end of the input is reached.
+	cands []Candidate
+	done  bool
+}
+
+func (c *completer) finish(cands []Candidate) {
+	c.cands = cands
+	c.done = true
+}
+
+// valueAt walks the value started by arg at index pos,
+// which is expected to be decoded by dec.
+// dec is nil if nothing is known about the value.
+func (c *completer) valueAt(dec decoder, pos int, arg string) {
+	if !c.p.more() {
+		switch arg {
+		case "[":
+			c.opened(dec)
+			return
+		case "--":
+			// Escaped strings can be anything.
+			c.finish(nil)
+			return
+		}
+	}
+
+	v, err := c.p.valueFrom(pos, arg)
+	if err != nil {
+		return // tolerate malformed values
+	}
+
+	dec = completeElem(dec)
+	switch v.t {
+	case arrayType:
+		if r, ok := v.i.(*cursorArrayReader); ok {
+			c.array(elemDecoder(dec), r)
+		}
+	case objectType:
+		if r, ok := v.i.(*cursorObjectReader); ok {
+			c.object(dec, r)
+		}
+	}
+}
+
+// opened reports candidates for the argument after a '['
+// that starts a value expected to be decoded by dec.
+func (c *completer) opened(dec decoder) {
+	dec = completeElem(dec)
+	var cands []Candidate
+	switch d := dec.(type) {
+	case *structDecoder:
+		cands = completeKeys(d, nil)
+	case *sliceDecoder, *arrayDecoder, *anyDecoder, nil:
+		cands = completeValues(elemDecoder(d))
+	}
+	c.finish(append(cands, Candidate{Value: "]"}))
+}
+
+func (c *completer) array(elem decoder, r *cursorArrayReader) {
+	for !c.done {
+		if !c.p.more() {
+			c.finish(append(completeValues(elem), Candidate{Value: "]"}))
+			return
+		}
+		if !r.more() {
+			return
+		}
+		c.valueAt(elem, r.last.pos, r.last.arg)
+	}
+}
+
+func (c *completer) object(dec decoder, r *cursorObjectReader) {
+	sd, _ := dec.(*structDecoder)
+	if sd != nil && (len(sd.args) > 0 || sd.argsRest >= 0) {
+		r.allowPositionals()
+	}
+
+	var (
+		used  = make(map[int]bool) // indexes into sd.fields
+		nargs int                  // number of positional arguments
+	)
+	for !c.done {
+		if !c.p.more() {
+			if r.terminated {
+				c.finish(nil)
+				return
+			}
+
+			var cands []Candidate
+			if sd != nil {
+				cands = completeKeys(sd, used)
+				if arg := positionalDecoder(sd, nargs); arg != nil {
+					cands = append(cands, completeValues(arg)...)
+				}
+			}
+			if r.open >= 0 {
+				cands = append(cands, Candidate{Value: "]"})
+			}
+			c.finish(cands)
+			return
+		}
+		if !r.more() {
+			return
+		}
+
+		if r.positional() {
+			arg := positionalDecoder(sd, nargs)
+			nargs++
+			if r.terminated {
+				continue
+			}
+			c.valueAt(arg, r.last.pos, r.last.arg)
+			continue
+		}
+
+		arg, pos := r.last.arg, r.last.pos
+		if arg == "--" || !strings.HasPrefix(arg, "--") {
+			continue // tolerate stray arguments
+		}
+
+		key := arg[2:]
+		idx := strings.IndexByte(key, '=')
+		if idx >= 0 {
+			key = key[:idx]
+		}
+
+		var fdec decoder
+		switch d := dec.(type) {
+		case *structDecoder:
+			if i, ok := d.fieldsByName[key]; ok {
+				used[i] = true
+				fdec = d.fields[i].p
+			} else if d.remaining != nil && d.remaining.p != nil {
+				fdec = d.remaining.p.v
+			}
+		case *mapDecoder:
+			fdec = d.v
+		}
+
+		if idx >= 0 {
+			c.valueAt(fdec, pos, arg[idx+3:])
+			continue
+		}
+		if !c.p.more() {
+			c.finish(completeValues(fdec))
+			return
+		}
+		vpos := c.p.index()
+		varg, _ := c.p.next()
+		c.valueAt(fdec, vpos, varg)
+	}
+}
+
+// completeKeys returns candidates for the keys of sd
+// that are not marked as used.
+func completeKeys(sd *structDecoder, used map[int]bool) []Candidate {
+	var cands []Candidate
+	for i, f := range sd.fields {
+		if f.positional || used[i] {
+			continue
+		}
+		desc, _, _ := strings.Cut(f.help, "\n")
+		cands = append(cands, Candidate{
+			Value:       "--" + f.names[0],
+			Description: desc,
+		})
+	}
+	return cands
+}
+
+// completeValues returns candidates for the start of a value
+// decoded by dec, or any value if dec is nil.
+func completeValues(dec decoder) []Candidate {
+	var values []string
+	switch d := dec.(type) {
+	case *boolDecoder:
+		values = []string{"-t", "-f"}
+	case *enumDecoder:
+		values = d.values
+	case *ptrDecoder:
+		for _, cand := range completeValues(d.e) {
+			values = append(values, cand.Value)
+		}
+		if !slices.Contains(values, "-n") {
+			values = append(values, "-n")
+		}
+	case *sliceDecoder, *mapDecoder:
+		values = []string{"[", "-n"}
+	case *arrayDecoder, *structDecoder:
+		values = []string{"["}
+	case *anyDecoder, nil:
+		values = []string{"-t", "-f", "-n", "["}
+	}
+
+	cands := make([]Candidate, len(values))
+	for i, v := range values {
+		cands[i] = Candidate{Value: v}
+	}
+	return cands
+}
+
+// positionalDecoder returns the decoder
+// for the positional argument at index i of sd,
+// or nil if sd doesn't have one.
+func positionalDecoder(sd *structDecoder, i int) decoder {
+	switch {
+	case sd == nil:
+		return nil
+	case i < len(sd.args):
+		return sd.fields[sd.args[i]].p
+	case sd.argsRest >= 0:
+		return elemDecoder(sd.fields[sd.argsRest].p)
+	default:
+		return nil
+	}
+}
+
+// completeElem looks through pointers in dec.
+func completeElem(dec decoder) decoder {
+	for {
+		d, ok := dec.(*ptrDecoder)
+		if !ok {
+			return dec
+		}
+		dec = d.e
+	}
+}
+
+// elemDecoder returns the decoder for items of the slice or array
+// decoded by dec, or nil if dec is not for a slice or array.
+func elemDecoder(dec decoder) decoder {
+	switch d := completeElem(dec).(type) {
+	case *sliceDecoder:
+		return d.e
+	case *arrayDecoder:
+		return d.e
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,177 @@
+package shon
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplete(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string  `shon:"host,required"`
+		Port *uint16 `shon:"port"`
+	}
+
+	type options struct {
+		Verbose bool              `shon:"verbose" help:"Print more output."`
+		Level   string            `shon:"level" enum:"debug info"`
+		Servers []server          `shon:"servers"`
+		Primary *server           `shon:"primary"`
+		Labels  map[string]string `shon:"labels"`
+		Extra   any               `shon:"extra"`
+		Mode    string            `shon:"mode,arg" enum:"fast slow"`
+		Files   []string          `shon:"files,args"`
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		want []string
+	}{
+		{
+			desc: "empty",
+			want: []string{"--verbose", "--level", "--servers", "--primary", "--labels", "--extra", "fast", "slow"},
+		},
+		{
+			desc: "key prefix",
+			give: []string{"--l"},
+			want: []string{"--level", "--labels"},
+		},
+		{
+			desc: "used keys",
+			give: []string{"--verbose", "-t", "--level=info", "--labels", "[]", ""},
+			want: []string{"--servers", "--primary", "--extra", "fast", "slow"},
+		},
+		{
+			desc: "bool",
+			give: []string{"--verbose", ""},
+			want: []string{"-t", "-f"},
+		},
+		{
+			desc: "enum",
+			give: []string{"--level", "d"},
+			want: []string{"debug"},
+		},
+		{
+			desc: "slice",
+			give: []string{"--servers", ""},
+			want: []string{"[", "-n"},
+		},
+		{
+			desc: "pointer to struct",
+			give: []string{"--primary", ""},
+			want: []string{"[", "-n"},
+		},
+		{
+			desc: "opened array",
+			give: []string{"--servers", "[", ""},
+			want: []string{"[", "]"},
+		},
+		{
+			desc: "opened object",
+			give: []string{"--servers", "[", "[", ""},
+			want: []string{"--host", "--port", "]"},
+		},
+		{
+			desc: "nested key",
+			give: []string{"--servers", "[", "[", "--host", "a", ""},
+			want: []string{"--port", "]"},
+		},
+		{
+			desc: "nested value",
+			give: []string{"--primary", "[", "--port", ""},
+			want: []string{"-n"},
+		},
+		{
+			desc: "array item",
+			give: []string{"--servers", "[", "[", "--host", "a", "]", ""},
+			want: []string{"[", "]"},
+		},
+		{
+			desc: "closed",
+			give: []string{"--servers", "[", "[", "--host", "a", "]", "]", "--s"},
+			want: nil,
+		},
+		{
+			desc: "after close",
+			give: []string{"--primary", "[", "--host", "a", "]", "--p"},
+			want: nil,
+		},
+		{
+			desc: "map",
+			give: []string{"--labels", "[", ""},
+			want: []string{"]"},
+		},
+		{
+			desc: "map value",
+			give: []string{"--labels", "[", "--a", "b", ""},
+			want: []string{"]"},
+		},
+		{
+			desc: "unknown key",
+			give: []string{"--foo", ""},
+			want: []string{"-t", "-f", "-n", "["},
+		},
+		{
+			desc: "any",
+			give: []string{"--extra", "[", ""},
+			want: []string{"-t", "-f", "-n", "[", "]"},
+		},
+		{
+			desc: "escaped value",
+			give: []string{"--level", "--", ""},
+		},
+		{
+			desc: "positional",
+			give: []string{"fast", ""},
+			want: []string{"--verbose", "--level", "--servers", "--primary", "--labels", "--extra"},
+		},
+		{
+			desc: "terminator",
+			give: []string{"--", ""},
+		},
+		{
+			desc: "malformed",
+			give: []string{"-x", "--verbose", "-t", "--lev"},
+			want: []string{"--level"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got []string
+			for _, c := range Complete(tt.give, &options{}) {
+				got = append(got, c.Value)
+			}
+			assert.Equal(t, tt.want, got, "args: %q", strings.Join(tt.give, " "))
+		})
+	}
+}
+
+func TestComplete_description(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Host string `shon:"host" help:"Host name.\nMay be an IP address."`
+		Port int    `shon:"port"`
+	}
+
+	assert.Equal(t, []Candidate{
+		{Value: "--host", Description: "Host name."},
+		{Value: "--port"},
+	}, Complete(nil, options{}))
+}
+
+func TestComplete_unsupported(t *testing.T) {
+	t.Parallel()
+
+	assert.Nil(t, Complete(nil, nil))
+	assert.Nil(t, Complete(nil, []string{}))
+	assert.Nil(t, Complete(nil, map[string]chan int{}))
+}
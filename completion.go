@@ -0,0 +1,564 @@
+package shon
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CompletionScript generates a shell completion script
+// for a program named prog that accepts arguments for v
+// with [ParseObject].
+// v must be a struct, a map, or a pointer to one of these.
+//
+// shell is one of "bash", "zsh", and "fish".
+// The script completes the keys of structs,
+// including those of nested objects opened with '[',
+// '-t' and '-f' for bools, '-n' for pointers,
+// the values listed in enum:".." struct tags,
+// and ']' to close the current object or array.
+//
+// The script is static: it does not call prog.
+// Load it in the shell's usual way, e.g. for bash:
+//
+//	source <(prog --completion bash)
+func CompletionScript(shell, prog string, v any) (string, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || (t.Kind() != reflect.Struct && t.Kind() != reflect.Map) {
+		return "", fmt.Errorf("expected a struct or map, got %v", reflect.TypeOf(v))
+	}
+
+	var write func(*strings.Builder, string, string, *complGraph)
+	switch shell {
+	case "bash":
+		write = writeBashCompletion
+	case "zsh":
+		write = writeZshCompletion
+	case "fish":
+		write = writeFishCompletion
+	default:
+		return "", fmt.Errorf("unsupported shell %q", shell)
+	}
+
+	dec, err := newDecoder(t)
+	if err != nil {
+		return "", err
+	}
+
+	var sb strings.Builder
+	write(&sb, prog, complIdent(prog), newComplGraph(dec))
+	return sb.String(), nil
+}
+
+// complKind is the kind of a complNode.
+type complKind int
+
+const (
+	complLeaf   complKind = iota // scalars
+	complObject                  // structs with known keys
+	complMap                     // objects with any keys
+	complArray                   // arrays and slices
+	complAny                     // anything
+)
+
+func (k complKind) String() string {
+	switch k {
+	case complObject:
+		return "obj"
+	case complMap:
+		return "map"
+	case complArray:
+		return "arr"
+	case complAny:
+		return "any"
+	default:
+		return "leaf"
+	}
+}
+
+// complGraph describes the values accepted by a type for completion.
+// Nodes are identified by their index in the graph.
+// The first node is the top-level value.
+type complGraph struct {
+	nodes []*complNode
+
+	// Node for values we know nothing about,
+	// e.g. those of unknown keys.
+	unknown int
+}
+
+type complNode struct {
+	kind complKind
+
+	// Candidates for a value of this node,
+	// e.g. "-t" and "-f" for bools.
+	values []string
+
+	// Canonical names of the keys of an object.
+	keys []string
+
+	// Nodes for the values of an object, by key.
+	// For maps and arrays, the "" key holds the node for all values.
+	children map[string]int
+}
+
+func newComplGraph(dec decoder) *complGraph {
+	var g complGraph
+	g.add(dec)
+	g.unknown = g.add(&anyDecoder{})
+	return &g
+}
+
+// add adds nodes for values decoded by dec,
+// and returns the index of the top-level node.
+func (g *complGraph) add(dec decoder) int {
+	id := len(g.nodes)
+	n := &complNode{}
+	g.nodes = append(g.nodes, n)
+
+	switch d := dec.(type) {
+	case *boolDecoder:
+		n.values = []string{"-t", "-f"}
+
+	case *enumDecoder:
+		n.values = slices.Clip(d.values)
+
+	case *ptrDecoder:
+		// Pointers accept everything their targets do.
+		g.nodes = g.nodes[:id]
+		id = g.add(d.e)
+		n = g.nodes[id]
+		n.values = append(n.values, "-n")
+
+	case *sliceDecoder:
+		n.kind = complArray
+		n.values = []string{"["}
+		n.children = map[string]int{"": g.add(d.e)}
+
+	case *arrayDecoder:
+		n.kind = complArray
+		n.values = []string{"["}
+		n.children = map[string]int{"": g.add(d.e)}
+
+	case *mapDecoder:
+		n.kind = complMap
+		n.values = []string{"["}
+		n.children = map[string]int{"": g.add(d.v)}
+
+	case *structDecoder:
+		n.kind = complObject
+		n.values = []string{"["}
+		n.children = make(map[string]int)
+		for _, f := range d.fields {
+			if f.positional {
+				continue
+			}
+			child := g.add(f.p)
+			n.keys = append(n.keys, f.names[0])
+			for _, name := range f.names {
+				if _, ok := n.children[name]; !ok {
+					n.children[name] = child
+				}
+			}
+		}
+
+	case *anyDecoder:
+		n.kind = complAny
+		n.values = []string{"-t", "-f", "-n", "["}
+
+	case *unmarshalerDecoder:
+		n.kind = complAny
+	}
+	return id
+}
+
+// idsByKind returns the indexes of nodes of each kind
+// other than complLeaf, in order of kind.
+func (g *complGraph) idsByKind() (kinds []complKind, ids [][]int) {
+	byKind := make(map[complKind][]int)
+	for id, n := range g.nodes {
+		if n.kind != complLeaf {
+			byKind[n.kind] = append(byKind[n.kind], id)
+		}
+	}
+	for k := complObject; k <= complAny; k++ {
+		if len(byKind[k]) > 0 {
+			kinds = append(kinds, k)
+			ids = append(ids, byKind[k])
+		}
+	}
+	return kinds, ids
+}
+
+// sortedChildren returns the keys of n.children in sorted order.
+func (n *complNode) sortedChildren() []string {
+	keys := make([]string, 0, len(n.children))
+	for k := range n.children {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// complIdent turns prog into a valid shell function name suffix.
+func complIdent(prog string) string {
+	ident := []byte(prog)
+	for i, c := range ident {
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9', c == '_':
+			// ok
+		default:
+			ident[i] = '_'
+		}
+	}
+	return string(ident)
+}
+
+// posixQuote quotes s for bash and zsh scripts unconditionally.
+func posixQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// fishQuote quotes s for fish scripts.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
+
+// writePOSIXHelpers writes the functions describing g
+// shared by the bash and zsh scripts.
+func writePOSIXHelpers(sb *strings.Builder, ident string, g *complGraph) {
+	fmt.Fprintf(sb, "_%v_kind() {\n\tcase $1 in\n", ident)
+	kinds, ids := g.idsByKind()
+	for i, k := range kinds {
+		fmt.Fprintf(sb, "\t%v) echo %v ;;\n", joinInts(ids[i], "|"), k)
+	}
+	sb.WriteString("\t*) echo leaf ;;\n\tesac\n}\n\n")
+
+	fmt.Fprintf(sb, "_%v_values() {\n\tcase $1 in\n", ident)
+	for id, n := range g.nodes {
+		if len(n.values) > 0 {
+			fmt.Fprintf(sb, "\t%v) printf '%%s\\n' %v ;;\n", id, quoteAll(n.values, posixQuote))
+		}
+	}
+	sb.WriteString("\tesac\n}\n\n")
+
+	fmt.Fprintf(sb, "_%v_keys() {\n\tcase $1 in\n", ident)
+	for id, n := range g.nodes {
+		if len(n.keys) > 0 {
+			fmt.Fprintf(sb, "\t%v) printf -- '--%%s\\n' %v ;;\n", id, quoteAll(n.keys, posixQuote))
+		}
+	}
+	sb.WriteString("\tesac\n}\n\n")
+
+	fmt.Fprintf(sb, "_%v_child() {\n\tcase $1:$2 in\n", ident)
+	for id, n := range g.nodes {
+		for _, key := range n.sortedChildren() {
+			if n.kind == complObject {
+				fmt.Fprintf(sb, "\t%v) echo %v ;;\n", posixQuote(strconv.Itoa(id)+":"+key), n.children[key])
+			} else {
+				fmt.Fprintf(sb, "\t%v:*) echo %v ;;\n", id, n.children[key])
+			}
+		}
+	}
+	fmt.Fprintf(sb, "\t*) echo %v ;;\n\tesac\n}\n", g.unknown)
+}
+
+func joinInts(ids []int, sep string) string {
+	strs := make([]string, len(ids))
+	for i, id := range ids {
+		strs[i] = strconv.Itoa(id)
+	}
+	return strings.Join(strs, sep)
+}
+
+func quoteAll(ss []string, quote func(string) string) string {
+	quoted := make([]string, len(ss))
+	for i, s := range ss {
+		quoted[i] = quote(s)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// _posixCompleteBody is the body of the bash and zsh functions
+// that determine the candidates for the current argument.
+//
+// It expects the arguments before the current one
+// to be in the array named by WORDS, starting at index FIRST,
+// and writes the candidates to stdout, one per line.
+//
+// The state of the parser is tracked as:
+//
+//   - stack: space-separated node indexes for the open objects and arrays
+//   - expect: node index of the value expected next, if any
+//   - literal: set if the next argument is a value escaped with '--'
+//   - positional: set after a '--' that ends the keys of the top-level object
+const _posixCompleteBody = `	local stack=0 expect= literal= positional= word top kind i
+	for ((i = FIRST; i < LAST; i++)); do
+		word=${WORDS[i]}
+		if [[ -n $literal ]]; then
+			literal=
+			continue
+		fi
+
+		top=${stack##* }
+		kind=$(_IDENT_kind "$top")
+		if [[ -z $expect && $kind == arr && $word != ']' ]]; then
+			expect=$(_IDENT_child "$top" '')
+		fi
+
+		if [[ -n $expect ]]; then
+			case $word in
+			--) literal=1 ;;
+			'[') stack="$stack $expect" ;;
+			esac
+			expect=
+			continue
+		fi
+
+		case $word in
+		']') [[ $stack == *' '* ]] && stack=${stack% *} ;;
+		'[') stack="$stack UNKNOWN" ;;
+		--) [[ $stack == *' '* ]] || positional=1 ;;
+		--?*=*) ;;
+		--?*)
+			if [[ $kind == obj || $kind == map ]]; then
+				expect=$(_IDENT_child "$top" "${word#--}")
+			fi
+			;;
+		esac
+	done
+
+	if [[ -n $literal || -n $positional ]]; then
+		return
+	fi
+
+	top=${stack##* }
+	kind=$(_IDENT_kind "$top")
+	if [[ -n $expect ]]; then
+		_IDENT_values "$expect"
+		return
+	fi
+
+	case $kind in
+	obj) _IDENT_keys "$top" ;;
+	arr) _IDENT_values "$(_IDENT_child "$top" '')" ;;
+	esac
+	if [[ $stack == *' '* ]]; then
+		echo ']'
+	fi
+`
+
+func writePOSIXComplete(sb *strings.Builder, ident string, g *complGraph, words, first, last string) {
+	fmt.Fprintf(sb, "_%v_candidates() {\n", ident)
+	sb.WriteString(strings.NewReplacer(
+		"IDENT", ident,
+		"UNKNOWN", strconv.Itoa(g.unknown),
+		"WORDS", words,
+		"FIRST", first,
+		"LAST", last,
+	).Replace(_posixCompleteBody))
+	sb.WriteString("}\n")
+}
+
+func writeBashCompletion(sb *strings.Builder, prog, ident string, g *complGraph) {
+	fmt.Fprintf(sb, "# bash completion for %v.\n", prog)
+	sb.WriteString("# Generated by go.abhg.dev/shon. DO NOT EDIT.\n\n")
+	writePOSIXHelpers(sb, ident, g)
+	sb.WriteString("\n")
+	writePOSIXComplete(sb, ident, g, "words", "1", "cword")
+	fmt.Fprintf(sb, `
+_%[1]v() {
+	# Bash splits '--key=value' into '--key', '=', 'value'.
+	# Join them back into one word.
+	local -a words=()
+	local cword=-1 join= i word
+	for ((i = 0; i <= COMP_CWORD; i++)); do
+		word=${COMP_WORDS[i]}
+		if [[ -n $join ]]; then
+			words[cword]+=$word
+			join=
+		elif ((cword > 0)) && [[ $word == = && ${words[cword]} == --?* ]]; then
+			words[cword]+=$word
+			join=1
+		else
+			words[++cword]=$word
+		fi
+	done
+
+	local cur=${words[cword]} prefix= cand
+	if [[ $cur == --?*=* ]]; then
+		# Complete the value after '='.
+		# Bash only replaces the text after '='
+		# if it's in COMP_WORDBREAKS.
+		words[cword]=${cur%%%%=*}
+		((cword++))
+		[[ $COMP_WORDBREAKS == *=* ]] || prefix=${cur%%%%=*}=
+		cur=${cur#*=}
+	fi
+
+	COMPREPLY=()
+	while IFS= read -r cand; do
+		if [[ $cand == "$cur"* ]]; then
+			case $cand in
+			'[' | ']') ;;
+			*) printf -v cand %%q "$cand" ;;
+			esac
+			COMPREPLY+=("$prefix$cand")
+		fi
+	done < <(_%[1]v_candidates)
+}
+
+complete -F _%[1]v %[2]v
+`, ident, posixQuote(prog))
+}
+
+func writeZshCompletion(sb *strings.Builder, prog, ident string, g *complGraph) {
+	fmt.Fprintf(sb, "#compdef %v\n", prog)
+	fmt.Fprintf(sb, "# zsh completion for %v.\n", prog)
+	sb.WriteString("# Generated by go.abhg.dev/shon. DO NOT EDIT.\n\n")
+	writePOSIXHelpers(sb, ident, g)
+	sb.WriteString("\n")
+	writePOSIXComplete(sb, ident, g, "words", "2", "CURRENT")
+	fmt.Fprintf(sb, `
+_%[1]v() {
+	local -a cands
+	cands=(${(f)"$(_%[1]v_candidates)"})
+	compadd -- "${cands[@]}"
+}
+
+if [[ $funcstack[1] == _%[1]v ]]; then
+	_%[1]v "$@"
+else
+	compdef _%[1]v %[2]v
+fi
+`, ident, posixQuote(prog))
+}
+
+func writeFishCompletion(sb *strings.Builder, prog, ident string, g *complGraph) {
+	fmt.Fprintf(sb, "# fish completion for %v.\n", prog)
+	sb.WriteString("# Generated by go.abhg.dev/shon. DO NOT EDIT.\n\n")
+
+	fmt.Fprintf(sb, "function __%v_kind\n    switch $argv[1]\n", ident)
+	kinds, ids := g.idsByKind()
+	for i, k := range kinds {
+		fmt.Fprintf(sb, "        case %v\n            echo %v\n", joinInts(ids[i], " "), k)
+	}
+	sb.WriteString("        case '*'\n            echo leaf\n    end\nend\n\n")
+
+	fmt.Fprintf(sb, "function __%v_values\n    switch $argv[1]\n", ident)
+	for id, n := range g.nodes {
+		if len(n.values) > 0 {
+			fmt.Fprintf(sb, "        case %v\n            printf '%%s\\n' %v\n", id, quoteAll(n.values, fishQuote))
+		}
+	}
+	sb.WriteString("    end\nend\n\n")
+
+	fmt.Fprintf(sb, "function __%v_keys\n    switch $argv[1]\n", ident)
+	for id, n := range g.nodes {
+		if len(n.keys) > 0 {
+			fmt.Fprintf(sb, "        case %v\n            printf -- '--%%s\\n' %v\n", id, quoteAll(n.keys, fishQuote))
+		}
+	}
+	sb.WriteString("    end\nend\n\n")
+
+	fmt.Fprintf(sb, "function __%v_child\n    switch \"$argv[1]:$argv[2]\"\n", ident)
+	for id, n := range g.nodes {
+		for _, key := range n.sortedChildren() {
+			if n.kind == complObject {
+				fmt.Fprintf(sb, "        case %v\n            echo %v\n", fishQuote(strconv.Itoa(id)+":"+key), n.children[key])
+			} else {
+				fmt.Fprintf(sb, "        case '%v:*'\n            echo %v\n", id, n.children[key])
+			}
+		}
+	}
+	fmt.Fprintf(sb, "        case '*'\n            echo %v\n    end\nend\n", g.unknown)
+
+	sb.WriteString(strings.NewReplacer(
+		"IDENT", ident,
+		"UNKNOWN", strconv.Itoa(g.unknown),
+		"PROG", fishQuote(prog),
+	).Replace(_fishComplete))
+}
+
+// _fishComplete is the fish counterpart of _posixCompleteBody.
+const _fishComplete = `
+function __IDENT_candidates
+    set -l words (commandline -opc)
+    set -e words[1]
+    set -l stack 0
+    set -l expect
+    set -l literal
+    set -l positional
+    for word in $words
+        if test -n "$literal"
+            set literal
+            continue
+        end
+
+        set -l top $stack[-1]
+        set -l kind (__IDENT_kind $top)
+        if test -z "$expect" -a "$kind" = arr -a "$word" != ']'
+            set expect (__IDENT_child $top '')
+        end
+
+        if test -n "$expect"
+            switch $word
+                case '--'
+                    set literal 1
+                case '['
+                    set -a stack $expect
+            end
+            set expect
+            continue
+        end
+
+        switch $word
+            case ']'
+                if test (count $stack) -gt 1
+                    set -e stack[-1]
+                end
+            case '['
+                set -a stack UNKNOWN
+            case '--'
+                if test (count $stack) -eq 1
+                    set positional 1
+                end
+            case '--*=*'
+            case '--?*'
+                if test "$kind" = obj -o "$kind" = map
+                    set expect (__IDENT_child $top (string sub -s 3 -- $word))
+                end
+        end
+    end
+
+    if test -n "$literal" -o -n "$positional"
+        return
+    end
+
+    set -l top $stack[-1]
+    set -l kind (__IDENT_kind $top)
+    if test -n "$expect"
+        __IDENT_values $expect
+        return
+    end
+
+    switch $kind
+        case obj
+            __IDENT_keys $top
+        case arr
+            __IDENT_values (__IDENT_child $top '')
+    end
+    if test (count $stack) -gt 1
+        echo ']'
+    end
+end
+
+complete -c PROG -f -a '(__IDENT_candidates)'
+`
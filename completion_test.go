@@ -0,0 +1,171 @@
+package shon
+
+import (
+	"flag"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var _update = flag.Bool("update", false, "update golden files")
+
+func TestCompletionScript(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string  `shon:"host,required"`
+		Port *uint16 `shon:"port"`
+	}
+
+	type options struct {
+		Verbose bool              `shon:"verbose"`
+		Level   string            `shon:"level" enum:"debug info 'very loud'"`
+		Servers []server          `shon:"servers"`
+		Labels  map[string]string `shon:"labels"`
+		Extra   any               `shon:"extra"`
+		Files   []string          `shon:"files,args"`
+	}
+
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		shell := shell
+		t.Run(shell, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := CompletionScript(shell, "my-tool", &options{})
+			require.NoError(t, err)
+
+			assertGolden(t, filepath.Join("testdata", "completion", "my-tool."+shell), got)
+		})
+	}
+}
+
+func TestCompletionScript_bash(t *testing.T) {
+	t.Parallel()
+
+	bash, err := exec.LookPath("bash")
+	if err != nil {
+		t.Skip("bash not found")
+	}
+
+	type options struct {
+		Verbose bool   `shon:"verbose"`
+		Level   string `shon:"level" enum:"debug 'very loud'"`
+	}
+
+	script, err := CompletionScript("bash", "my-tool", &options{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc      string
+		words     []string // COMP_WORDS after the program name
+		wordbreak string   // COMP_WORDBREAKS
+		want      []string
+	}{
+		{
+			desc:  "keys",
+			words: []string{"--v"},
+			want:  []string{"--verbose"},
+		},
+		{
+			desc:  "quoted values",
+			words: []string{"--level", ""},
+			want:  []string{"debug", `very\ loud`},
+		},
+		{
+			desc:  "value after =",
+			words: []string{"--level", "=", "v"},
+			want:  []string{`very\ loud`},
+		},
+		{
+			desc:  "empty value after =",
+			words: []string{"--verbose", "=", ""},
+			want:  []string{"-t", "-f"},
+		},
+		{
+			desc:  "after --key=value",
+			words: []string{"--level", "=", "debug", "--v"},
+			want:  []string{"--verbose"},
+		},
+		{
+			desc:      "= is not a word break",
+			words:     []string{"--level=d"},
+			wordbreak: " ",
+			want:      []string{"--level=debug"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			words := append([]string{"my-tool"}, tt.words...)
+			wordbreak := tt.wordbreak
+			if wordbreak == "" {
+				wordbreak = "\"'><=;|&(:"
+			}
+			cmd := exec.Command(bash, "--norc", "--noprofile", "-c", script+`
+COMP_WORDBREAKS=$1
+shift
+COMP_WORDS=("$@")
+COMP_CWORD=$(($# - 1))
+_my_tool
+printf '%s\n' "${COMPREPLY[@]}"
+`, "bash", wordbreak)
+			cmd.Args = append(cmd.Args, words...)
+
+			out, err := cmd.Output()
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, strings.Split(strings.TrimSuffix(string(out), "\n"), "\n"))
+		})
+	}
+}
+
+func TestCompletionScript_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		shell   string
+		give    any
+		wantErr string
+	}{
+		{
+			desc:    "unknown shell",
+			shell:   "tcsh",
+			give:    struct{}{},
+			wantErr: `unsupported shell "tcsh"`,
+		},
+		{
+			desc:    "not an object",
+			shell:   "bash",
+			give:    []string{},
+			wantErr: "expected a struct or map, got []string",
+		},
+		{
+			desc:    "nil",
+			shell:   "bash",
+			wantErr: "expected a struct or map, got <nil>",
+		},
+		{
+			desc:    "unsupported type",
+			shell:   "bash",
+			give:    map[string]chan int{},
+			wantErr: "unsupported type chan int",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := CompletionScript(tt.shell, "prog", tt.give)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
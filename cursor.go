@@ -17,6 +17,9 @@ type cursor interface {
 	//
 	// Must return true if the prior 'more' call returned true.
 	next() (v string, ok bool)
+
+	// Returns the index of the next item in the input stream.
+	index() int
 }
 
 // sliceCursor implements cursor around a slice of values.
@@ -46,3 +49,7 @@ func (c *sliceCursor) peek() (s string, ok bool) {
 	}
 	return "", false
 }
+
+func (c *sliceCursor) index() int {
+	return c.pos
+}
@@ -14,6 +14,7 @@ func TestSliceCursor(t *testing.T) {
 
 		sc := sliceCursor{args: []string{"foo", "bar"}}
 
+		assert.Equal(t, 0, sc.index())
 		if assert.True(t, sc.more(), "expected more") {
 			s, ok := sc.peek()
 			assert.True(t, ok)
@@ -24,6 +25,7 @@ func TestSliceCursor(t *testing.T) {
 			assert.Equal(t, "foo", s)
 		}
 
+		assert.Equal(t, 1, sc.index())
 		if assert.True(t, sc.more(), "expected more") {
 			s, ok := sc.peek()
 			assert.True(t, ok)
@@ -34,6 +36,7 @@ func TestSliceCursor(t *testing.T) {
 			assert.Equal(t, "bar", s)
 		}
 
+		assert.Equal(t, 2, sc.index())
 		if assert.False(t, sc.more(), "expected no more") {
 			_, ok := sc.peek()
 			assert.False(t, ok)
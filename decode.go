@@ -1,21 +1,124 @@
 package shon
 
 import (
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"reflect"
+	"slices"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Number holds numeric values that could be integers or floats.
 type Number = json.Number
 
-var _stringType = reflect.TypeOf("")
+var (
+	_stringType  = reflect.TypeOf("")
+	_stringsType = reflect.TypeOf([]string(nil))
+)
 
 type decodeCtx struct {
 	// Whether to use json.Number
 	UseNumber bool
+
+	// Arguments being decoded, for error messages.
+	Args []string
+
+	// Cursor over Args.
+	// Used to find where values end.
+	Cursor cursor
+
+	// Path to the value being decoded, for error messages.
+	// See DecodeError.Path.
+	Path string
+
+	// If non-nil, decoders record errors for values
+	// they could not decode here and move on.
+	// See AllErrors.
+	Errs *[]error
+
+	// Whether to merge into existing values, and whether to append
+	// to existing slices when doing so.
+	// See Merge and AppendSlices.
+	Merge, AppendSlices bool
+
+	// How to handle repeated keys in objects.
+	DupKeys DuplicateKeyPolicy
+
+	// Existing value that the value being decoded
+	// should be merged into.
+	// This is only set if Merge is true,
+	// and may be invalid if there's no existing value.
+	Into reflect.Value
+
+	// Values decoded for existing pointers when merging.
+	// These are written to the pointers only if decoding succeeds.
+	Writes *pointerWrites
+}
+
+// into returns a copy of ctx for merging into existing value v
+// if merging is enabled.
+func (ctx decodeCtx) into(v reflect.Value) decodeCtx {
+	if ctx.Merge {
+		ctx.Into = v
+	}
+	return ctx
+}
+
+// tolerate reports whether decoding may continue after err,
+// which occurred while decoding v.
+// If so, it records err and discards the rest of v, returning nil.
+// Otherwise, it returns err unchanged.
+func (ctx decodeCtx) tolerate(err error, v value) error {
+	var decodeErr *DecodeError
+	if ctx.Errs == nil || !errors.As(err, &decodeErr) {
+		return err
+	}
+
+	*ctx.Errs = append(*ctx.Errs, err)
+	return skip(v)
+}
+
+// duplicateKey handles value v for a key
+// that was already specified earlier in the same object
+// according to the DupKeys policy.
+//
+// It reports whether v should be decoded.
+// If not, v has been skipped.
+func (ctx decodeCtx) duplicateKey(t reflect.Type, v value, key string) (bool, error) {
+	switch ctx.DupKeys {
+	case DuplicateFirstWins:
+		return false, skip(v)
+	case DuplicateError:
+		v.pos = v.kpos
+		return false, ctx.errorf(t, v, "duplicate key %q", key)
+	default:
+		return true, nil
+	}
+}
+
+// accumulates reports whether values for repeated keys
+// should be collected into dec.
+func (ctx decodeCtx) accumulates(dec decoder) (*sliceDecoder, bool) {
+	if ctx.DupKeys != DuplicateAccumulate {
+		return nil, false
+	}
+	sd, ok := dec.(*sliceDecoder)
+	return sd, ok
+}
+
+// accumulatesAny reports whether values for repeated keys
+// should be collected into a []any for dec.
+func (ctx decodeCtx) accumulatesAny(dec decoder) (*anyDecoder, bool) {
+	if ctx.DupKeys != DuplicateAccumulate {
+		return nil, false
+	}
+	ad, ok := dec.(*anyDecoder)
+	return ad, ok
 }
 
 type decoder interface {
@@ -23,9 +126,52 @@ type decoder interface {
 }
 
 func newDecoder(t reflect.Type) (decoder, error) {
+	return newFieldDecoder(t, fieldTag{})
+}
+
+// newFieldDecoder builds a decoder for t,
+// honoring options specified on the tag of the struct field it came from.
+// The tag applies to t and its element types, but not to nested structs.
+func newFieldDecoder(t reflect.Type, tag fieldTag) (decoder, error) {
+	dec, err := newTypeDecoder(t, tag)
+	if err != nil || tag.enum == nil {
+		return dec, err
+	}
+
+	switch dec.(type) {
+	case *ptrDecoder, *sliceDecoder, *arrayDecoder, *mapDecoder, *structDecoder, *anyDecoder:
+		// The enum applies to the elements.
+		return dec, nil
+	}
+	return &enumDecoder{t: t, d: dec, values: tag.enum}, nil
+}
+
+// newTypeDecoder builds a decoder for t.
+// See newFieldDecoder.
+func newTypeDecoder(t reflect.Type, tag fieldTag) (decoder, error) {
+	switch t {
+	case _durationType:
+		return &durationDecoder{t: t}, nil
+	case _timeType:
+		layout := tag.layout
+		if len(layout) == 0 {
+			layout = time.RFC3339
+		}
+		return &timeDecoder{t: t, layout: layout}, nil
+	}
+
+	// The method set of *T includes that of T,
+	// so this covers both, T and *T implementing Unmarshaler.
+	if reflect.PointerTo(t).Implements(_unmarshalerType) {
+		return &unmarshalerDecoder{t: t}, nil
+	}
+	if reflect.PointerTo(t).Implements(_textUnmarshalerType) {
+		return &textUnmarshalerDecoder{t: t}, nil
+	}
+
 	switch t.Kind() {
 	case reflect.Pointer:
-		e, err := newDecoder(t.Elem())
+		e, err := newFieldDecoder(t.Elem(), tag)
 		if err != nil {
 			return nil, err
 		}
@@ -35,7 +181,7 @@ func newDecoder(t reflect.Type) (decoder, error) {
 		}, nil
 
 	case reflect.Slice:
-		e, err := newDecoder(t.Elem())
+		e, err := newFieldDecoder(t.Elem(), tag)
 		if err != nil {
 			return nil, err
 		}
@@ -45,7 +191,7 @@ func newDecoder(t reflect.Type) (decoder, error) {
 		}, nil
 
 	case reflect.Array:
-		e, err := newDecoder(t.Elem())
+		e, err := newFieldDecoder(t.Elem(), tag)
 		if err != nil {
 			return nil, err
 		}
@@ -56,11 +202,11 @@ func newDecoder(t reflect.Type) (decoder, error) {
 		}, nil
 
 	case reflect.Map:
-		k, err := newDecoder(t.Key())
+		k, err := newFieldDecoder(t.Key(), tag)
 		if err != nil {
 			return nil, err
 		}
-		v, err := newDecoder(t.Elem())
+		v, err := newFieldDecoder(t.Elem(), tag)
 		if err != nil {
 			return nil, err
 		}
@@ -100,13 +246,47 @@ func newDecoder(t reflect.Type) (decoder, error) {
 	return nil, fmt.Errorf("unsupported type %v", t)
 }
 
+// enumDecoder restricts the scalars and strings accepted by d
+// to a fixed set.
+type enumDecoder struct {
+	t      reflect.Type
+	d      decoder
+	values []string
+}
+
+func (d *enumDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	switch t.t {
+	case scalarType, stringType:
+		if !slices.Contains(d.values, t.s) {
+			err := ctx.newError(d.t, t, fmt.Errorf("unexpected value %q: must be one of %v", t.s, strings.Join(d.values, ", ")))
+			err.Suggestions = suggest(t.s, d.values)
+			return reflect.Value{}, err
+		}
+	}
+	return d.d.Decode(ctx, t)
+}
+
 type ptrDecoder struct {
 	t reflect.Type
 	e decoder
 }
 
 func (d *ptrDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
-	v, err := d.e.Decode(ctx, t)
+	if t.t == nullType {
+		return reflect.Zero(d.t), nil
+	}
+
+	if into := ctx.Into; into.IsValid() && !into.IsNil() {
+		// Decode into the existing value in-place.
+		v, err := d.e.Decode(ctx.into(ctx.Writes.load(into)), t)
+		if err != nil {
+			return v, err
+		}
+		ctx.Writes.store(into, v)
+		return into, nil
+	}
+
+	v, err := d.e.Decode(ctx.into(reflect.Value{}), t)
 	if err != nil {
 		return v, err
 	}
@@ -116,13 +296,148 @@ func (d *ptrDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	return p, nil
 }
 
+// pointerWrites holds values for existing pointers
+// that are decoded into in-place when merging.
+// They're written to the pointers with commit
+// so that a failed Parse leaves its target as-is.
+//
+// A nil pointerWrites writes values right away.
+type pointerWrites struct {
+	ptrs   []reflect.Value // in the order they were first stored
+	values map[pointerKey]reflect.Value
+}
+
+type pointerKey struct {
+	t    reflect.Type
+	addr uintptr
+}
+
+func newPointerKey(ptr reflect.Value) pointerKey {
+	return pointerKey{t: ptr.Type(), addr: ptr.Pointer()}
+}
+
+// load returns the value that ptr points to,
+// taking pending writes into account.
+func (w *pointerWrites) load(ptr reflect.Value) reflect.Value {
+	if w != nil {
+		if v, ok := w.values[newPointerKey(ptr)]; ok {
+			return v
+		}
+	}
+	return ptr.Elem()
+}
+
+// store records v as the new value for ptr.
+func (w *pointerWrites) store(ptr, v reflect.Value) {
+	if w == nil {
+		ptr.Elem().Set(v)
+		return
+	}
+
+	key := newPointerKey(ptr)
+	if w.values == nil {
+		w.values = make(map[pointerKey]reflect.Value)
+	}
+	if _, ok := w.values[key]; !ok {
+		w.ptrs = append(w.ptrs, ptr)
+	}
+	w.values[key] = v
+}
+
+// commit writes the stored values to their pointers.
+func (w *pointerWrites) commit() {
+	if w == nil {
+		return
+	}
+	for _, ptr := range w.ptrs {
+		ptr.Elem().Set(w.values[newPointerKey(ptr)])
+	}
+}
+
+var _textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+// textUnmarshalerDecoder decodes scalars and strings
+// into types that implement encoding.TextUnmarshaler.
+type textUnmarshalerDecoder struct {
+	t reflect.Type
+}
+
+func (d *textUnmarshalerDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	switch t.t {
+	case scalarType, stringType:
+		// ok
+	default:
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
+	}
+
+	v := reflect.New(d.t)
+	if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(t.s)); err != nil {
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
+	}
+	return v.Elem(), nil
+}
+
+var (
+	_durationType = reflect.TypeOf(time.Duration(0))
+	_timeType     = reflect.TypeOf(time.Time{})
+)
+
+// durationDecoder decodes time.Duration values
+// with time.ParseDuration.
+type durationDecoder struct {
+	t reflect.Type
+}
+
+func (d *durationDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	switch t.t {
+	case scalarType, stringType:
+		// ok
+	default:
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
+	}
+
+	dur, err := time.ParseDuration(t.s)
+	if err != nil {
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
+	}
+
+	v := reflect.New(d.t).Elem()
+	v.SetInt(int64(dur))
+	return v, nil
+}
+
+// timeDecoder decodes time.Time values
+// with time.Parse and the given layout.
+type timeDecoder struct {
+	t      reflect.Type
+	layout string
+}
+
+func (d *timeDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	switch t.t {
+	case scalarType, stringType:
+		// ok
+	default:
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
+	}
+
+	tm, err := time.Parse(d.layout, t.s)
+	if err != nil {
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
+	}
+
+	v := reflect.New(d.t).Elem()
+	v.Set(reflect.ValueOf(tm))
+	return v, nil
+}
+
 type boolDecoder struct {
 	t reflect.Type
 }
 
-func (d *boolDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *boolDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != boolType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -135,14 +450,14 @@ type intDecoder struct {
 	bits int
 }
 
-func (d *intDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *intDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != scalarType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	i, err := strconv.ParseInt(t.s, 10, d.bits)
 	if err != nil {
-		return reflect.Value{}, fmt.Errorf("bad %v: %w", d.t, err)
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -155,14 +470,14 @@ type uintDecoder struct {
 	bits int
 }
 
-func (d *uintDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *uintDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != scalarType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	u, err := strconv.ParseUint(t.s, 10, d.bits)
 	if err != nil {
-		return reflect.Value{}, fmt.Errorf("bad %v: %w", d.t, err)
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -175,14 +490,14 @@ type floatDecoder struct {
 	bits int
 }
 
-func (d *floatDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *floatDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != scalarType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	f, err := strconv.ParseFloat(t.s, d.bits)
 	if err != nil {
-		return reflect.Value{}, fmt.Errorf("bad %v: %w", d.t, err)
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -195,14 +510,14 @@ type complexDecoder struct {
 	bits int
 }
 
-func (d *complexDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *complexDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != scalarType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	c, err := strconv.ParseComplex(t.s, d.bits)
 	if err != nil {
-		return reflect.Value{}, fmt.Errorf("bad %v: %w", d.t, err)
+		return reflect.Value{}, ctx.errorf(d.t, t, "bad %v: %w", d.t, err)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -214,12 +529,12 @@ type stringDecoder struct {
 	t reflect.Type
 }
 
-func (d *stringDecoder) Decode(_ decodeCtx, t value) (reflect.Value, error) {
+func (d *stringDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	switch t.t {
 	case scalarType, stringType:
 		// ok
 	default:
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	v := reflect.New(d.t).Elem()
@@ -233,20 +548,29 @@ type sliceDecoder struct {
 }
 
 func (d *sliceDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	if t.t == nullType {
+		return reflect.Zero(d.t), nil
+	}
 	if t.t != arrayType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	v := reflect.MakeSlice(d.t, 0, 0)
-	for r := t.i.(reader); r.more(); {
+	if ctx.AppendSlices && ctx.Into.IsValid() {
+		v = reflect.AppendSlice(v, ctx.Into)
+	}
+	for r, idx := t.i.(reader), 0; r.more(); idx++ {
 		i, err := r.next()
 		if err != nil {
 			return v, err
 		}
 
-		e, err := d.e.Decode(ctx, i)
+		e, err := d.e.Decode(ctx.withIndex(idx), i)
 		if err != nil {
-			return v, err
+			if err := ctx.tolerate(err, i); err != nil {
+				return v, err
+			}
+			continue
 		}
 
 		v = reflect.Append(v, e)
@@ -254,6 +578,58 @@ func (d *sliceDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	return v, nil
 }
 
+// accumulate decodes t as more items for the slice items,
+// returning the combined slice.
+// items is not modified.
+// See DuplicateAccumulate.
+func (d *sliceDecoder) accumulate(ctx decodeCtx, items reflect.Value, t value) (reflect.Value, error) {
+	if t.t == nullType && !mayBeNull(d.t.Elem()) {
+		// Null adds no items.
+		// It's a nil slice only if there were no items before.
+		return items, nil
+	}
+
+	v := reflect.MakeSlice(d.t, 0, items.Len()+1)
+	v = reflect.AppendSlice(v, items)
+
+	if t.t == arrayType && !mayBeArray(d.t.Elem()) {
+		more, err := d.Decode(ctx.into(reflect.Value{}), t)
+		if err != nil {
+			return v, err
+		}
+		return reflect.AppendSlice(v, more), nil
+	}
+
+	e, err := d.e.Decode(ctx.into(reflect.Value{}), t)
+	if err != nil {
+		return v, err
+	}
+	return reflect.Append(v, e), nil
+}
+
+// mayBeArray reports whether values of type t
+// may be decoded from arrays.
+func mayBeArray(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Slice, reflect.Array, reflect.Interface:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(_unmarshalerType)
+}
+
+// mayBeNull reports whether values of type t
+// may be decoded from null.
+func mayBeNull(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map, reflect.Interface:
+		return true
+	}
+	return reflect.PointerTo(t).Implements(_unmarshalerType)
+}
+
 type arrayDecoder struct {
 	t   reflect.Type
 	e   decoder
@@ -262,23 +638,30 @@ type arrayDecoder struct {
 
 func (d *arrayDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != arrayType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	v := reflect.New(d.t).Elem()
 	for r, idx := t.i.(reader), 0; r.more(); idx++ {
-		if idx >= d.len {
-			return v, fmt.Errorf("too many values: at most %v expected", d.len)
-		}
-
 		i, err := r.next()
 		if err != nil {
 			return v, err
 		}
 
-		e, err := d.e.Decode(ctx, i)
+		if idx >= d.len {
+			err := ctx.withIndex(idx).errorf(d.t, i, "too many values: at most %v expected", d.len)
+			if err := ctx.tolerate(err, i); err != nil {
+				return v, err
+			}
+			continue
+		}
+
+		e, err := d.e.Decode(ctx.withIndex(idx), i)
 		if err != nil {
-			return v, err
+			if err := ctx.tolerate(err, i); err != nil {
+				return v, err
+			}
+			continue
 		}
 
 		v.Index(idx).Set(e)
@@ -293,133 +676,530 @@ type mapDecoder struct {
 }
 
 func (d *mapDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	if t.t == nullType {
+		return reflect.Zero(d.t), nil
+	}
 	if t.t != objectType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
-	v := reflect.MakeMap(d.t)
+	v := d.newMap(ctx)
+	seen := make(map[any]bool) // keys specified in this object
 	for r := t.i.(objectReader); r.more(); {
 		ks, vs, err := r.next()
 		if err != nil {
 			return v, err
 		}
 
-		key, err := d.k.Decode(ctx, value{t: scalarType, s: ks})
-		if err != nil {
-			return v, err
+		if err := d.decodeEntry(ctx, v, seen, ks, vs); err != nil {
+			if err := ctx.tolerate(err, vs); err != nil {
+				return v, err
+			}
 		}
+	}
+	return v, nil
+}
 
-		val, err := d.v.Decode(ctx, vs)
-		if err != nil {
-			return v, err
+// newMap builds an empty map to decode into,
+// or a copy of the existing map if merging.
+func (d *mapDecoder) newMap(ctx decodeCtx) reflect.Value {
+	v := reflect.MakeMap(d.t)
+	if ctx.Into.IsValid() {
+		for iter := ctx.Into.MapRange(); iter.Next(); {
+			v.SetMapIndex(iter.Key(), iter.Value())
 		}
+	}
+	return v
+}
 
-		v.SetMapIndex(key, val)
+// decodeEntry decodes the value vs for key ks into map v.
+// seen holds the keys previously specified in the same object,
+// and whether their values were accumulated into a []any.
+func (d *mapDecoder) decodeEntry(ctx decodeCtx, v reflect.Value, seen map[any]bool, ks string, vs value) error {
+	kctx := ctx.withKey(ks)
+	key, err := d.k.Decode(kctx, value{t: scalarType, s: ks, pos: vs.kpos})
+	if err != nil {
+		return err
 	}
-	return v, nil
+
+	listed, dup := seen[key.Interface()]
+	seen[key.Interface()] = listed
+	if dup {
+		ok, err := kctx.duplicateKey(d.t, vs, ks)
+		if err != nil || !ok {
+			return err
+		}
+	}
+
+	var val reflect.Value
+	if sd, ok := ctx.accumulates(d.v); ok {
+		items := v.MapIndex(key)
+		if !items.IsValid() || (!dup && !ctx.AppendSlices) {
+			items = reflect.Zero(d.t.Elem())
+		}
+		val, err = sd.accumulate(kctx, items, vs)
+	} else if ad, ok := ctx.accumulatesAny(d.v); ok && dup {
+		val, err = ad.Decode(kctx.into(reflect.Value{}), vs)
+		if err == nil {
+			val = ad.accumulate(v.MapIndex(key), val, listed)
+			seen[key.Interface()] = true
+		}
+	} else {
+		val, err = d.v.Decode(kctx.into(v.MapIndex(key)), vs)
+	}
+	if err != nil {
+		return err
+	}
+
+	v.SetMapIndex(key, val)
+	return nil
 }
 
 type structDecoder struct {
 	t            reflect.Type
 	fields       []structField
 	fieldsByName map[string]int // name => index into .fields
+
+	// Field receiving unknown keys, if any.
+	// See fieldTag.remaining.
+	remaining *remainingField
+
+	// Indexes into .fields of positional arguments, in order,
+	// and of the field receiving the rest of them, or -1.
+	// See fieldTag.arg and fieldTag.args.
+	args     []int
+	argsRest int
+}
+
+type remainingField struct {
+	index []int // see fieldInfo.index
+
+	// Decoder for values of the map.
+	// nil if this is a passthrough field.
+	p *mapDecoder
 }
 
 func newStructDecoder(t reflect.Type) (*structDecoder, error) {
+	infos, err := visibleFields(t)
+	if err != nil {
+		return nil, err
+	}
+
 	p := structDecoder{
 		t:            t,
 		fieldsByName: make(map[string]int),
+		argsRest:     -1,
 	}
-	for i := 0; i < t.NumField(); i++ {
-		sf, ok, err := newStructField(i, t.Field(i))
-		if err != nil {
-			return nil, err
+	var restName string // name of the args field
+	for _, info := range infos {
+		if info.tag.cmd {
+			continue // see ParseCommand
 		}
-		if !ok {
+		if info.tag.passthrough {
+			p.remaining = &remainingField{index: info.index}
 			continue
 		}
+		if info.tag.remaining {
+			f := info.field
+			fdec, err := newFieldDecoder(f.Type, info.tag)
+			if err != nil {
+				return nil, err
+			}
+			mdec, ok := fdec.(*mapDecoder)
+			if !ok {
+				// The map type has custom decoding.
+				return nil, fmt.Errorf("field %v: remaining requires a map, got %v", f.Name, f.Type)
+			}
+			p.remaining = &remainingField{index: info.index, p: mdec}
+			continue
+		}
+
+		sf, err := newStructField(info)
+		if err != nil {
+			return nil, err
+		}
 
-		for _, name := range sf.names {
-			p.fieldsByName[name] = len(p.fields)
+		switch {
+		case info.tag.arg:
+			p.args = append(p.args, len(p.fields))
+		case info.tag.args:
+			if _, ok := sf.p.(*sliceDecoder); !ok {
+				return nil, fmt.Errorf("field %v: args requires a slice, got %v", info.field.Name, info.field.Type)
+			}
+			if p.argsRest >= 0 {
+				return nil, fmt.Errorf("fields %v and %v: only one field may receive remaining positional arguments",
+					restName, info.field.Name)
+			}
+			p.argsRest = len(p.fields)
+			restName = info.field.Name
+		default:
+			p.fieldsByName[sf.names[0]] = len(p.fields)
 		}
 		p.fields = append(p.fields, sf)
 	}
+
+	// Canonical names take precedence over other names.
+	// Positional arguments are not addressed by name.
+	for i, sf := range p.fields {
+		if sf.positional {
+			continue
+		}
+		for _, name := range sf.names[1:] {
+			if _, ok := p.fieldsByName[name]; !ok {
+				p.fieldsByName[name] = i
+			}
+		}
+	}
 	return &p, nil
 }
 
 func (d *structDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 	if t.t != objectType {
-		return reflect.Value{}, fmt.Errorf("expected %v, got %v", d.t, t.t)
+		return reflect.Value{}, ctx.errorf(d.t, t, "expected %v, got %v", d.t, t.t)
 	}
 
 	v := reflect.New(d.t).Elem()
-	for r := t.i.(objectReader); r.more(); {
+	if ctx.Into.IsValid() {
+		v.Set(ctx.Into)
+	}
+	seen := make([]bool, len(d.fields))   // fields that were specified
+	listed := make([]bool, len(d.fields)) // any fields accumulated into a []any
+
+	// Map or slice receiving unknown keys, if any.
+	// Built on first use.
+	var (
+		remaining     reflect.Value
+		remainingSeen map[any]bool
+	)
+	r := t.i.(objectReader)
+	pr, _ := r.(positionalReader)
+	if pr != nil && (len(d.args) > 0 || d.argsRest >= 0) {
+		pr.allowPositionals()
+	} else {
+		pr = nil
+	}
+
+	// Requests for help are recognized
+	// unless the struct has its own help field.
+	hr, _ := r.(helpReader)
+	if _, ok := d.fieldsByName["help"]; ok {
+		hr = nil
+	} else if hr != nil {
+		hr.detectHelp()
+	}
+
+	var nargs int // number of positional arguments seen
+	for r.more() {
+		if hr != nil && hr.help() {
+			return v, ErrHelp
+		}
+		if pr != nil && pr.positional() {
+			value, err := pr.nextPositional()
+			if err != nil {
+				return v, err
+			}
+			if err := d.decodeArg(ctx, v, seen, nargs, value); err != nil {
+				if err := ctx.tolerate(err, value); err != nil {
+					return v, err
+				}
+			}
+			nargs++
+			continue
+		}
+
 		key, value, err := r.next()
 		if err != nil {
 			return v, err
 		}
 
 		fidx, ok := d.fieldsByName[key]
+		if !ok && d.remaining != nil && d.remaining.p == nil {
+			// Passthrough: Keep the key and value as-is.
+			if err := skip(value); err != nil {
+				return v, err
+			}
+
+			if !remaining.IsValid() {
+				remaining = reflect.MakeSlice(_stringsType, 0, 0)
+				if ctx.Merge && ctx.AppendSlices {
+					if existing := fieldByIndex(v, d.remaining.index, false); existing.IsValid() {
+						remaining = reflect.AppendSlice(remaining, existing)
+					}
+				}
+			}
+			raw := ctx.Args[value.kpos:ctx.Cursor.index()]
+			remaining = reflect.AppendSlice(remaining, reflect.ValueOf(raw))
+			continue
+		}
+		if !ok && d.remaining != nil {
+			if !remaining.IsValid() {
+				remaining = d.remaining.p.newMap(ctx.into(fieldByIndex(v, d.remaining.index, false)))
+				remainingSeen = make(map[any]bool)
+			}
+			if err := d.remaining.p.decodeEntry(ctx, remaining, remainingSeen, key, value); err != nil {
+				if err := ctx.tolerate(err, value); err != nil {
+					return v, err
+				}
+			}
+			continue
+		}
 		if !ok {
-			return v, fmt.Errorf("unknown field %q", key)
+			err := ctx.withKey(key).unknownKeyError(d.t, value, key, d.names())
+			if err := ctx.tolerate(err, value); err != nil {
+				return v, err
+			}
+			continue
 		}
 
 		f := d.fields[fidx]
-		fval, err := f.p.Decode(ctx, value)
+		fctx := ctx.withKey(key)
+		if seen[fidx] {
+			ok, err := fctx.duplicateKey(d.t, value, key)
+			if err != nil {
+				if err := ctx.tolerate(err, value); err != nil {
+					return v, err
+				}
+				continue
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		var fval reflect.Value
+		if sd, ok := ctx.accumulates(f.p); ok {
+			items := fieldByIndex(v, f.index, false)
+			if !items.IsValid() || (!seen[fidx] && !ctx.AppendSlices) {
+				items = reflect.Zero(f.t)
+			}
+			fval, err = sd.accumulate(fctx, items, value)
+		} else if ad, ok := ctx.accumulatesAny(f.p); ok && seen[fidx] {
+			fval, err = ad.Decode(fctx.into(reflect.Value{}), value)
+			if err == nil {
+				fval = ad.accumulate(fieldByIndex(v, f.index, false), fval, listed[fidx])
+				listed[fidx] = true
+			}
+		} else {
+			fval, err = f.p.Decode(fctx.into(fieldByIndex(v, f.index, false)), value)
+		}
+		seen[fidx] = true
 		if err != nil {
-			return v, err
+			if err := ctx.tolerate(err, value); err != nil {
+				return v, err
+			}
+			continue
 		}
 
-		v.Field(f.idx).Set(fval)
+		fieldByIndex(v, f.index, true).Set(fval)
 	}
+
+	if remaining.IsValid() {
+		fieldByIndex(v, d.remaining.index, true).Set(remaining)
+	}
+
+	// Fields of existing values are left alone when merging.
+	if !ctx.Into.IsValid() {
+		if err := d.setDefaults(ctx, v, seen); err != nil {
+			return v, ctx.wrapError(d.t, t, err)
+		}
+	}
+
+	var missing []string
+	for i, f := range d.fields {
+		// Existing values satisfy required fields when merging.
+		if f.required && !seen[i] && (!ctx.Into.IsValid() || isZeroField(v, f.index)) {
+			missing = append(missing, ctx.withKey(f.names[0]).Path)
+		}
+	}
+	if len(missing) > 0 {
+		err := ctx.wrapError(d.t, t, &MissingFieldsError{Paths: missing})
+		if err := ctx.tolerate(err, t); err != nil {
+			return v, err
+		}
+	}
+
 	return v, nil
 }
 
+// decodeArg decodes positional argument number n into struct v.
+func (d *structDecoder) decodeArg(ctx decodeCtx, v reflect.Value, seen []bool, n int, value value) error {
+	var fidx int
+	switch {
+	case n < len(d.args):
+		fidx = d.args[n]
+	case d.argsRest >= 0:
+		fidx = d.argsRest
+	default:
+		return ctx.errorf(d.t, value, "unexpected positional argument %q", ctx.Args[value.pos])
+	}
+
+	f := d.fields[fidx]
+	fctx := ctx.withKey(f.names[0])
+
+	var (
+		fval reflect.Value
+		err  error
+	)
+	if fidx == d.argsRest {
+		items := fieldByIndex(v, f.index, false)
+		if !items.IsValid() || (!seen[fidx] && !ctx.AppendSlices) {
+			items = reflect.Zero(f.t)
+		}
+		fval, err = f.p.(*sliceDecoder).accumulate(fctx, items, value)
+	} else {
+		fval, err = f.p.Decode(fctx.into(fieldByIndex(v, f.index, false)), value)
+	}
+	seen[fidx] = true
+	if err != nil {
+		return err
+	}
+
+	fieldByIndex(v, f.index, true).Set(fval)
+	return nil
+}
+
+// setDefaults sets the fields of v that were not specified
+// to their default values, if any.
+// seen reports which fields were specified, and may be nil.
+//
+// Defaults are also applied to the fields of nested structs
+// that were not specified.
+func (d *structDecoder) setDefaults(ctx decodeCtx, v reflect.Value, seen []bool) error {
+	for i, f := range d.fields {
+		if seen != nil && seen[i] {
+			continue
+		}
+
+		if f.dflt != nil {
+			dv, err := f.defaultValue(ctx.UseNumber)
+			if err != nil {
+				return fmt.Errorf("field %v: bad default: %w", f.names[0], err)
+			}
+			fieldByIndex(v, f.index, true).Set(dv)
+		} else if sd, ok := f.p.(*structDecoder); ok {
+			if fv := fieldByIndex(v, f.index, false); fv.IsValid() {
+				if err := sd.setDefaults(ctx, fv, nil); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// names returns the canonical names of all fields of the struct
+// that are addressed by name.
+func (d *structDecoder) names() []string {
+	names := make([]string, 0, len(d.fields))
+	for _, f := range d.fields {
+		if !f.positional {
+			names = append(names, f.names[0])
+		}
+	}
+	return names
+}
+
 type structField struct {
-	t   reflect.Type
-	p   decoder
-	idx int // index in struct.Field(i)
+	t     reflect.Type
+	p     decoder
+	index []int // see fieldInfo.index
 
 	// List of names this field accepts.
-	// If 'shon:".."' is set, this contains just one.
-	// Otherwise it contains the field name
-	// and our guess at its kebab case version.
+	// See fieldTag.names.
 	names []string
-}
 
-func newStructField(idx int, f reflect.StructField) (structField, bool, error) {
-	if !f.IsExported() {
-		return structField{}, false, nil
-	}
+	required   bool // whether the field must be specified
+	positional bool // whether the field is a positional argument
+	help       string
+
+	// Arguments for the default value of this field.
+	// nil if the field doesn't have a default.
+	dflt []string
+}
 
-	fdec, err := newDecoder(f.Type)
+func newStructField(info fieldInfo) (structField, error) {
+	f, tag := info.field, info.tag
+	fdec, err := newFieldDecoder(f.Type, tag)
 	if err != nil {
-		return structField{}, false, err
+		return structField{}, err
 	}
 
-	var names []string
-	if name, ok := f.Tag.Lookup("shon"); ok {
-		if name == "-" {
-			return structField{}, false, nil
+	sf := structField{
+		t:          f.Type,
+		p:          fdec,
+		index:      info.index,
+		names:      info.names,
+		required:   tag.required,
+		positional: tag.positional(),
+		dflt:       tag.dflt,
+		help:       tag.help,
+	}
+	if sf.dflt != nil {
+		// Report bad defaults early.
+		if _, err := sf.defaultValue(false); err != nil {
+			return structField{}, fmt.Errorf("field %v: bad default: %w", f.Name, err)
 		}
-		names = []string{name}
-	} else {
-		names = []string{f.Name, toKebab(f.Name)}
 	}
+	return sf, nil
+}
 
-	return structField{
-		t:     f.Type,
-		p:     fdec,
-		idx:   idx,
-		names: names,
-	}, true, nil
+// defaultValue decodes a new copy of the default value of this field.
+func (f *structField) defaultValue(useNumber bool) (reflect.Value, error) {
+	ctx := decodeCtx{UseNumber: useNumber, Args: f.dflt}
+	return decodeArgs(ctx, (*parser).value, f.p)
 }
 
 type anyDecoder struct {
 	t reflect.Type
 }
 
+// accumulate combines v with prev, the value of a repeated key,
+// into a []any holding both.
+// listed reports whether prev is already such a []any.
+// See DuplicateAccumulate.
+func (d *anyDecoder) accumulate(prev, v reflect.Value, listed bool) reflect.Value {
+	if listed {
+		return reflect.Append(prev.Elem(), v)
+	}
+	return reflect.Append(reflect.MakeSlice(reflect.SliceOf(d.t), 0, 2), prev, v)
+}
+
 func (d *anyDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	var existing reflect.Value // concrete value held by ctx.Into
+	if ctx.Into.IsValid() && !ctx.Into.IsNil() {
+		existing = ctx.Into.Elem()
+	}
+
+	// Decode into existing non-nil pointers in-place.
+	if t.t != nullType && existing.Kind() == reflect.Pointer && !existing.IsNil() {
+		dec, err := newDecoder(existing.Type())
+		if err != nil {
+			return reflect.Value{}, ctx.wrapError(d.t, t, err)
+		}
+		return dec.Decode(ctx.into(existing), t)
+	}
+
+	// Merge into existing values of other types
+	// that hold arrays or objects, like []string or structs,
+	// keeping their type.
+	if existing.IsValid() && (t.t == arrayType || t.t == objectType) &&
+		existing.Type() != reflect.SliceOf(d.t) && existing.Type() != reflect.MapOf(_stringType, d.t) {
+		dec, err := newDecoder(existing.Type())
+		if err != nil {
+			return reflect.Value{}, ctx.wrapError(d.t, t, err)
+		}
+		switch dec.(type) {
+		case *sliceDecoder, *arrayDecoder:
+			if t.t == arrayType {
+				return dec.Decode(ctx.into(existing), t)
+			}
+		case *structDecoder, *mapDecoder:
+			if t.t == objectType {
+				return dec.Decode(ctx.into(existing), t)
+			}
+		}
+	}
+
 	v := reflect.New(d.t).Elem()
 	switch t.t {
 	case nullType:
@@ -440,7 +1220,7 @@ func (d *anyDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 				} else {
 					// This is impossible unless there's a
 					// bug in isNumeric.
-					return v, fmt.Errorf("bad number %q", arg)
+					return v, ctx.errorf(d.t, t, "bad number %q", arg)
 				}
 			}
 		} else {
@@ -448,15 +1228,21 @@ func (d *anyDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 		}
 	case arrayType:
 		items := reflect.MakeSlice(reflect.SliceOf(d.t), 0, 0)
-		for r := t.i.(reader); r.more(); {
+		if ctx.AppendSlices && existing.IsValid() && existing.Type() == items.Type() {
+			items = reflect.AppendSlice(items, existing)
+		}
+		for r, idx := t.i.(reader), 0; r.more(); idx++ {
 			i, err := r.next()
 			if err != nil {
 				return v, err
 			}
 
-			e, err := d.Decode(ctx, i)
+			e, err := d.Decode(ctx.withIndex(idx), i)
 			if err != nil {
-				return v, err
+				if err := ctx.tolerate(err, i); err != nil {
+					return v, err
+				}
+				continue
 			}
 
 			items = reflect.Append(items, e)
@@ -465,23 +1251,62 @@ func (d *anyDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
 
 	case objectType:
 		m := reflect.MakeMap(reflect.MapOf(_stringType, d.t))
+		if existing.IsValid() && existing.Type() == m.Type() {
+			for iter := existing.MapRange(); iter.Next(); {
+				m.SetMapIndex(iter.Key(), iter.Value())
+			}
+		}
+		var (
+			seen  = make(map[string]struct{}) // keys specified in this object
+			lists = make(map[string]struct{}) // keys with accumulated values
+		)
 		for r := t.i.(objectReader); r.more(); {
 			key, vs, err := r.next()
 			if err != nil {
 				return v, err
 			}
 
-			val, err := d.Decode(ctx, vs)
+			kctx := ctx.withKey(key)
+			_, dup := seen[key]
+			seen[key] = struct{}{}
+			if dup {
+				ok, err := kctx.duplicateKey(d.t, vs, key)
+				if err != nil {
+					if err := ctx.tolerate(err, vs); err != nil {
+						return v, err
+					}
+					continue
+				}
+				if !ok {
+					continue
+				}
+			}
+
+			k := reflect.ValueOf(key)
+			accumulate := dup && ctx.DupKeys == DuplicateAccumulate
+			if !accumulate {
+				kctx = kctx.into(m.MapIndex(k))
+			}
+			val, err := d.Decode(kctx, vs)
 			if err != nil {
-				return v, err
+				if err := ctx.tolerate(err, vs); err != nil {
+					return v, err
+				}
+				continue
+			}
+
+			if prev := m.MapIndex(k); accumulate && prev.IsValid() {
+				_, listed := lists[key]
+				val = d.accumulate(prev, val, listed)
+				lists[key] = struct{}{}
 			}
 
-			m.SetMapIndex(reflect.ValueOf(key), val)
+			m.SetMapIndex(k, val)
 		}
 		v.Set(m)
 
 	default:
-		return v, fmt.Errorf("unexpected %v", t.t)
+		return v, ctx.errorf(d.t, t, "unexpected %v", t.t)
 	}
 
 	return v, nil
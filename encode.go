@@ -0,0 +1,734 @@
+package shon
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Marshal encodes v into a list of SHON arguments.
+//
+// The arguments produced by Marshal may be fed back into [Parse]
+// to decode them into a value of the same type.
+//
+// The following provides a listing of the type of v
+// and the form Marshal will produce for it.
+//
+//   - bool: -t or -f
+//   - any int, uint, float, or complex type:
+//     the number as-is
+//   - string: the string as-is, or preceded by '--'
+//     if it would otherwise be mistaken for something else
+//   - slice, array: a collection of values surrounded by '[', ']',
+//     or '[]' if it's empty
+//   - map: key-value pairs where the key is prefixed with '--',
+//     surrounded by '[', ']', or '[--]' if it's empty.
+//     Keys are sorted.
+//   - struct: key-value pairs where the key is the field name
+//     in kebab-case or the name specified with the shon:".." tag,
+//     surrounded by '[', ']', or '[--]' if it has no fields.
+//     Subcommands (shon:",cmd") are omitted.
+//     Positional argument fields (shon:",arg" and shon:",args")
+//     must be empty; see [MarshalObject] for those.
+//   - time.Duration: the duration in the form "1h2m3s"
+//   - time.Time: the time in RFC 3339 format,
+//     or the layout specified with shon:",layout=..."
+//   - types implementing [encoding.TextMarshaler]:
+//     the output of MarshalText as a string
+//   - nil pointers, slices, maps, and interfaces: -n
+//   - non-nil pointers and interfaces: encoded as the target value
+//
+// Marshal returns an error for values that cannot be represented in SHON.
+// This includes channels, functions,
+// infinite and NaN floating point values,
+// and map keys that are empty or contain '='.
+func Marshal(v any) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	if !rv.IsValid() {
+		return []string{"-n"}, nil
+	}
+
+	enc, err := newEncoder(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	return enc.Encode(nil, rv)
+}
+
+// MarshalObject is a variant of [Marshal] that omits the surrounding
+// '[', ']' of a top-level object, mirroring [ParseObject].
+//
+// For example, given:
+//
+//	User{FirstName: "Jack", LastName: "Sparrow"}
+//
+// MarshalObject will produce:
+//
+//	--first-name Jack --last-name Sparrow
+//
+// Positional argument fields of a struct
+// (shon:",arg" and shon:",args") follow its keys,
+// after a '--' if an argument would otherwise be read as a key.
+//
+// v must be a struct, a map, or a pointer to one of these.
+// Structs that don't encode as objects, like time.Time
+// and types implementing [encoding.TextMarshaler], are rejected.
+func MarshalObject(v any) ([]string, error) {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		rv = rv.Elem()
+	}
+
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map:
+		// ok
+	default:
+		return nil, fmt.Errorf("expected a struct or map, got %v", reflect.TypeOf(v))
+	}
+
+	enc, err := newEncoder(rv.Type())
+	if err != nil {
+		return nil, err
+	}
+
+	switch enc := enc.(type) {
+	case *structEncoder:
+		return enc.encodeObject(nil, rv)
+	case *mapEncoder:
+		// ok
+	default:
+		// e.g. time.Time, or types implementing encoding.TextMarshaler
+		return nil, fmt.Errorf("%v does not encode as an object", rv.Type())
+	}
+
+	args, err := enc.Encode(nil, rv)
+	if err != nil {
+		return nil, err
+	}
+
+	// Maps are always encoded as '[--]', '-n',
+	// or '[' followed by key-value pairs and ']'.
+	if len(args) < 2 {
+		return nil, nil
+	}
+	return args[1 : len(args)-1], nil
+}
+
+type encoder interface {
+	// Encode appends the SHON form of v to args.
+	Encode(args []string, v reflect.Value) ([]string, error)
+}
+
+var _numberType = reflect.TypeOf(Number(""))
+
+func newEncoder(t reflect.Type) (encoder, error) {
+	return newFieldEncoder(t, fieldTag{})
+}
+
+// newFieldEncoder builds an encoder for t,
+// honoring options specified on the tag of the struct field it came from.
+// The tag applies to t and its element types, but not to nested structs.
+func newFieldEncoder(t reflect.Type, tag fieldTag) (encoder, error) {
+	switch t {
+	case _numberType:
+		return numberEncoder{}, nil
+	case _durationType:
+		return durationEncoder{}, nil
+	case _timeType:
+		layout := tag.layout
+		if len(layout) == 0 {
+			layout = time.RFC3339Nano
+		}
+		return timeEncoder{layout: layout}, nil
+	}
+
+	// Pointers are dereferenced by ptrEncoder below
+	// before they reach textMarshalerEncoder.
+	if t.Kind() != reflect.Pointer && reflect.PointerTo(t).Implements(_textMarshalerType) {
+		return &textMarshalerEncoder{t: t}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		e, err := newFieldEncoder(t.Elem(), tag)
+		if err != nil {
+			return nil, err
+		}
+		return &ptrEncoder{e: e}, nil
+
+	case reflect.Slice:
+		e, err := newFieldEncoder(t.Elem(), tag)
+		if err != nil {
+			return nil, err
+		}
+		return &sliceEncoder{e: e}, nil
+
+	case reflect.Array:
+		e, err := newFieldEncoder(t.Elem(), tag)
+		if err != nil {
+			return nil, err
+		}
+		return &arrayEncoder{e: e}, nil
+
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.Pointer:
+			return nil, fmt.Errorf("unsupported map key type %v", t.Key())
+		case reflect.String,
+			reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+			reflect.Float32, reflect.Float64,
+			reflect.Complex64, reflect.Complex128:
+			// ok
+		default:
+			if !reflect.PointerTo(t.Key()).Implements(_textMarshalerType) {
+				return nil, fmt.Errorf("unsupported map key type %v", t.Key())
+			}
+		}
+
+		k, err := newFieldEncoder(t.Key(), tag)
+		if err != nil {
+			return nil, err
+		}
+		v, err := newFieldEncoder(t.Elem(), tag)
+		if err != nil {
+			return nil, err
+		}
+		return &mapEncoder{k: k, v: v}, nil
+
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return anyEncoder{}, nil
+		}
+
+	case reflect.Struct:
+		return newStructEncoder(t)
+
+	case reflect.String:
+		return stringEncoder{}, nil
+
+	case reflect.Bool:
+		return boolEncoder{}, nil
+
+	case reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64, reflect.Int:
+		return intEncoder{}, nil
+
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uint:
+		return uintEncoder{}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return floatEncoder{bits: t.Bits()}, nil
+
+	case reflect.Complex64, reflect.Complex128:
+		return complexEncoder{bits: t.Bits()}, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %v", t)
+}
+
+type ptrEncoder struct {
+	e encoder
+}
+
+func (e *ptrEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	if v.IsNil() {
+		return append(args, "-n"), nil
+	}
+	return e.e.Encode(args, v.Elem())
+}
+
+type boolEncoder struct{}
+
+func (boolEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	if v.Bool() {
+		return append(args, "-t"), nil
+	}
+	return append(args, "-f"), nil
+}
+
+type intEncoder struct{}
+
+func (intEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	return append(args, strconv.FormatInt(v.Int(), 10)), nil
+}
+
+type uintEncoder struct{}
+
+func (uintEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	return append(args, strconv.FormatUint(v.Uint(), 10)), nil
+}
+
+type floatEncoder struct {
+	bits int
+}
+
+func (e floatEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	f := v.Float()
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return args, fmt.Errorf("unsupported value %v", f)
+	}
+	return append(args, strconv.FormatFloat(f, 'g', -1, e.bits)), nil
+}
+
+type complexEncoder struct {
+	bits int
+}
+
+func (e complexEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	c := v.Complex()
+	for _, f := range []float64{real(c), imag(c)} {
+		if math.IsInf(f, 0) || math.IsNaN(f) {
+			return args, fmt.Errorf("unsupported value %v", c)
+		}
+	}
+
+	// FormatComplex surrounds the value with parentheses.
+	// This is fine to keep: it prevents negative values
+	// from being mistaken for flags.
+	return append(args, strconv.FormatComplex(c, 'g', -1, e.bits)), nil
+}
+
+type numberEncoder struct{}
+
+func (numberEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	s := v.String()
+	if isNumeric(s) {
+		return append(args, s), nil
+	}
+	return appendString(args, s), nil
+}
+
+type durationEncoder struct{}
+
+func (durationEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	return appendString(args, time.Duration(v.Int()).String()), nil
+}
+
+type timeEncoder struct {
+	layout string
+}
+
+func (e timeEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	tm := v.Interface().(time.Time)
+	return appendString(args, tm.Format(e.layout)), nil
+}
+
+var _textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+
+// textMarshalerEncoder encodes types that implement
+// encoding.TextMarshaler as strings.
+type textMarshalerEncoder struct {
+	t reflect.Type
+}
+
+func (e *textMarshalerEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	text, err := e.text(v)
+	if err != nil {
+		return args, err
+	}
+	return appendString(args, text), nil
+}
+
+func (e *textMarshalerEncoder) text(v reflect.Value) (string, error) {
+	if !e.t.Implements(_textMarshalerType) {
+		// MarshalText has a pointer receiver.
+		if !v.CanAddr() {
+			p := reflect.New(e.t).Elem()
+			p.Set(v)
+			v = p
+		}
+		v = v.Addr()
+	}
+
+	bs, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return "", fmt.Errorf("marshal %v: %w", e.t, err)
+	}
+	return string(bs), nil
+}
+
+type stringEncoder struct{}
+
+func (stringEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	return appendString(args, v.String()), nil
+}
+
+// appendString appends the SHON form of string s to args,
+// escaping it with '--' if necessary.
+func appendString(args []string, s string) []string {
+	if needsEscape(s) {
+		args = append(args, "--")
+	}
+	return append(args, s)
+}
+
+// needsEscape reports whether s must be preceded by '--'
+// to be read back as the same string.
+func needsEscape(s string) bool {
+	switch s {
+	case "":
+		return false
+	case "[", "]", "[]", "[--]":
+		return true
+	}
+	return s[0] == '-' || isNumeric(s)
+}
+
+type sliceEncoder struct {
+	e encoder
+}
+
+func (e *sliceEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	if v.IsNil() {
+		return append(args, "-n"), nil
+	}
+	return encodeArray(args, e.e, v)
+}
+
+type arrayEncoder struct {
+	e encoder
+}
+
+func (e *arrayEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	return encodeArray(args, e.e, v)
+}
+
+// encodeArray encodes the items of a slice or array v with e.
+func encodeArray(args []string, e encoder, v reflect.Value) ([]string, error) {
+	if v.Len() == 0 {
+		return append(args, "[]"), nil
+	}
+
+	args = append(args, "[")
+	for i := 0; i < v.Len(); i++ {
+		var err error
+		args, err = e.Encode(args, v.Index(i))
+		if err != nil {
+			return args, err
+		}
+	}
+	return append(args, "]"), nil
+}
+
+type mapEncoder struct {
+	k encoder
+	v encoder
+}
+
+func (e *mapEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	if v.IsNil() {
+		return append(args, "-n"), nil
+	}
+	if v.Len() == 0 {
+		return append(args, "[--]"), nil
+	}
+
+	type entry struct {
+		key   string
+		value reflect.Value
+	}
+
+	entries := make([]entry, 0, v.Len())
+	for iter := v.MapRange(); iter.Next(); {
+		key, err := encodeKey(e.k, iter.Key())
+		if err != nil {
+			return args, err
+		}
+		entries = append(entries, entry{key: key, value: iter.Value()})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].key < entries[j].key
+	})
+
+	args = append(args, "[")
+	for _, ent := range entries {
+		var err error
+		args = append(args, "--"+ent.key)
+		args, err = e.v.Encode(args, ent.value)
+		if err != nil {
+			return args, err
+		}
+	}
+	return append(args, "]"), nil
+}
+
+// encodeKey encodes a map key with e.
+// The returned string does not include the leading '--'.
+func encodeKey(e encoder, v reflect.Value) (string, error) {
+	// Keys are never escaped,
+	// so strings and text are used verbatim.
+	var key string
+	switch e := e.(type) {
+	case *textMarshalerEncoder:
+		var err error
+		key, err = e.text(v)
+		if err != nil {
+			return "", err
+		}
+
+	case stringEncoder:
+		key = v.String()
+
+	default:
+		args, err := e.Encode(nil, v)
+		if err != nil {
+			return "", err
+		}
+		if len(args) != 1 {
+			// Unreachable unless newEncoder allows
+			// a composite map key.
+			return "", fmt.Errorf("unsupported map key %v", v)
+		}
+		key = args[0]
+	}
+
+	switch {
+	case len(key) == 0:
+		return "", errors.New("unsupported map key: key must not be empty")
+	case strings.Contains(key, "="):
+		return "", fmt.Errorf("unsupported map key %q: key must not contain '='", key)
+	}
+	return key, nil
+}
+
+type structEncoder struct {
+	fields []fieldEncoder
+
+	// Fields receiving positional arguments, in order,
+	// and the field receiving the rest of them, if any.
+	// See structDecoder.args and structDecoder.argsRest.
+	args []fieldEncoder
+	rest *fieldEncoder // encodes items of the field
+}
+
+type fieldEncoder struct {
+	name  string
+	index []int // see fieldInfo.index
+	e     encoder
+
+	// Whether this field holds unknown keys of the object
+	// that should be inlined into it.
+	// See fieldTag.remaining and fieldTag.passthrough.
+	remaining, passthrough bool
+}
+
+func newStructEncoder(t reflect.Type) (*structEncoder, error) {
+	infos, err := visibleFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	var e structEncoder
+	for _, info := range infos {
+		if info.tag.cmd {
+			// Subcommands can only be parsed with ParseCommand.
+			continue
+		}
+
+		ft := info.field.Type
+		if info.tag.args {
+			if ft.Kind() != reflect.Slice {
+				return nil, fmt.Errorf("field %v: args requires a slice, got %v", info.field.Name, ft)
+			}
+			ft = ft.Elem()
+		}
+
+		fenc, err := newFieldEncoder(ft, info.tag)
+		if err != nil {
+			return nil, err
+		}
+
+		f := fieldEncoder{
+			name:        info.names[0],
+			index:       info.index,
+			e:           fenc,
+			remaining:   info.tag.remaining,
+			passthrough: info.tag.passthrough,
+		}
+		switch {
+		case info.tag.arg:
+			e.args = append(e.args, f)
+		case info.tag.args:
+			e.rest = &f
+		default:
+			e.fields = append(e.fields, f)
+		}
+	}
+	return &e, nil
+}
+
+func (e *structEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	// Objects can only hold positional arguments
+	// at the top level of ParseObject.
+	for _, f := range e.positionals() {
+		if fv := fieldByIndex(v, f.index, false); fv.IsValid() && !fv.IsZero() {
+			return args, fmt.Errorf("field %v: positional arguments can only be encoded with MarshalObject", f.name)
+		}
+	}
+
+	start := len(args)
+	args, err := e.encodeKeys(append(args, "["), v)
+	if err != nil {
+		return args, err
+	}
+
+	if len(args) == start+1 {
+		return append(args[:start], "[--]"), nil
+	}
+	return append(args, "]"), nil
+}
+
+// encodeObject encodes v like Encode,
+// but without the surrounding '[', ']'
+// and with its positional arguments after its keys.
+func (e *structEncoder) encodeObject(args []string, v reflect.Value) ([]string, error) {
+	args, err := e.encodeKeys(args, v)
+	if err != nil {
+		return args, err
+	}
+
+	var pos positionalEncoder
+	for _, f := range e.args {
+		if fv := fieldByIndex(v, f.index, false); fv.IsValid() {
+			args, err = pos.Encode(args, f, fv)
+			if err != nil {
+				return args, err
+			}
+		}
+	}
+	if f := e.rest; f != nil {
+		if fv := fieldByIndex(v, f.index, false); fv.IsValid() {
+			for i := 0; i < fv.Len(); i++ {
+				args, err = pos.Encode(args, *f, fv.Index(i))
+				if err != nil {
+					return args, err
+				}
+			}
+		}
+	}
+	return args, nil
+}
+
+// positionals returns the fields receiving positional arguments.
+func (e *structEncoder) positionals() []fieldEncoder {
+	if e.rest == nil {
+		return e.args
+	}
+	return append(e.args[:len(e.args):len(e.args)], *e.rest)
+}
+
+// positionalEncoder encodes positional arguments of an object in order.
+type positionalEncoder struct {
+	terminated bool // whether the '--' that ends the keys was added
+}
+
+// Encode appends the positional argument v for field f to args.
+func (pe *positionalEncoder) Encode(args []string, f fieldEncoder, v reflect.Value) ([]string, error) {
+	arg, err := f.e.Encode(nil, v)
+	if err != nil {
+		return args, fmt.Errorf("field %v: %w", f.name, err)
+	}
+
+	// Positional arguments can't start with '--'
+	// unless they follow the '--' that ends the keys,
+	// after which they're taken verbatim.
+	if !pe.terminated && strings.HasPrefix(arg[0], "--") {
+		args = append(args, "--")
+		pe.terminated = true
+	}
+	if !pe.terminated {
+		return append(args, arg...), nil
+	}
+
+	s, ok := verbatimArg(arg)
+	if !ok {
+		return args, fmt.Errorf("field %v: cannot encode %q after an argument that starts with '--'",
+			f.name, strings.Join(arg, " "))
+	}
+	return append(args, s), nil
+}
+
+func (e *structEncoder) encodeKeys(args []string, v reflect.Value) ([]string, error) {
+	for _, f := range e.fields {
+		fv := fieldByIndex(v, f.index, false)
+		if !fv.IsValid() {
+			continue // inside a nil embedded pointer
+		}
+
+		var err error
+		switch {
+		case f.passthrough:
+			args = append(args, fv.Interface().([]string)...)
+		case f.remaining:
+			args, err = encodeRemaining(args, f.e, fv)
+		default:
+			args = append(args, "--"+f.name)
+			args, err = f.e.Encode(args, fv)
+		}
+		if err != nil {
+			return args, fmt.Errorf("field %v: %w", f.name, err)
+		}
+	}
+	return args, nil
+}
+
+// verbatimArg returns the argument that reads as the value encoded as arg
+// when it's taken verbatim as a scalar,
+// and reports whether there is one.
+func verbatimArg(arg []string) (string, bool) {
+	switch {
+	case len(arg) == 2 && arg[0] == "--":
+		return arg[1], true // escaped string
+	case len(arg) == 1:
+		switch arg[0] {
+		case "-t", "-f", "-n", "[", "]", "[]", "[--]":
+			return "", false
+		}
+		return arg[0], true
+	default:
+		return "", false
+	}
+}
+
+// encodeRemaining encodes the entries of map v with e
+// without the surrounding '[', ']'.
+func encodeRemaining(args []string, e encoder, v reflect.Value) ([]string, error) {
+	if v.Len() == 0 {
+		return args, nil
+	}
+
+	start := len(args)
+	args, err := e.Encode(args, v)
+	if err != nil {
+		return args, err
+	}
+
+	// Drop the '[' and ']'.
+	args = append(args[:start], args[start+1:len(args)-1]...)
+	return args, nil
+}
+
+type anyEncoder struct{}
+
+func (anyEncoder) Encode(args []string, v reflect.Value) ([]string, error) {
+	if v.IsNil() {
+		return append(args, "-n"), nil
+	}
+
+	v = v.Elem()
+	e, err := newEncoder(v.Type())
+	if err != nil {
+		return args, err
+	}
+	return e.Encode(args, v)
+}
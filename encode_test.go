@@ -0,0 +1,471 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/big"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarshal(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give any
+		want []string
+	}{
+		{desc: "nil", give: nil, want: []string{"-n"}},
+		{desc: "bool/true", give: true, want: []string{"-t"}},
+		{desc: "bool/false", give: false, want: []string{"-f"}},
+		{desc: "int", give: 42, want: []string{"42"}},
+		{desc: "int/negative", give: -10, want: []string{"-10"}},
+		{desc: "uint", give: uint8(255), want: []string{"255"}},
+		{desc: "float", give: 4.2, want: []string{"4.2"}},
+		{desc: "float32", give: float32(0.1), want: []string{"0.1"}},
+		{desc: "complex", give: complex(-1, 2), want: []string{"(-1+2i)"}},
+		{desc: "string", give: "hello", want: []string{"hello"}},
+		{desc: "string/spaces", give: "hello world", want: []string{"hello world"}},
+		{desc: "string/empty", give: "", want: []string{""}},
+		{desc: "string/numeric", give: "10", want: []string{"--", "10"}},
+		{desc: "string/negative", give: "-10", want: []string{"--", "-10"}},
+		{desc: "string/dash", give: "-", want: []string{"--", "-"}},
+		{desc: "string/double dash", give: "--", want: []string{"--", "--"}},
+		{desc: "string/flag", give: "-t", want: []string{"--", "-t"}},
+		{desc: "string/open", give: "[", want: []string{"--", "["}},
+		{desc: "string/close", give: "]", want: []string{"--", "]"}},
+		{desc: "string/empty object", give: "[--]", want: []string{"--", "[--]"}},
+		{desc: "number", give: Number("1e3"), want: []string{"1e3"}},
+		{desc: "slice", give: []string{"beep", "boop"}, want: []string{"[", "beep", "boop", "]"}},
+		{desc: "slice/empty", give: []int{}, want: []string{"[]"}},
+		{desc: "slice/nil", give: []int(nil), want: []string{"-n"}},
+		{
+			desc: "slice/leading key-like string",
+			give: []string{"--foo", "bar"},
+			want: []string{"[", "--", "--foo", "bar", "]"},
+		},
+		{desc: "array", give: [3]int{1, 2, 3}, want: []string{"[", "1", "2", "3", "]"}},
+		{
+			desc: "map/sorted",
+			give: map[string]int{"b": 20, "a": 10},
+			want: []string{"[", "--a", "10", "--b", "20", "]"},
+		},
+		{
+			desc: "map/int keys",
+			give: map[int]string{2: "qux", -1: "bar"},
+			want: []string{"[", "---1", "bar", "--2", "qux", "]"},
+		},
+		{desc: "map/empty", give: map[string]int{}, want: []string{"[--]"}},
+		{desc: "map/nil", give: map[string]int(nil), want: []string{"-n"}},
+		{desc: "pointer", give: ptrOf(ptrOf("foo")), want: []string{"foo"}},
+		{desc: "pointer/nil", give: (*int)(nil), want: []string{"-n"}},
+		{
+			desc: "struct",
+			give: struct {
+				FirstName string
+				LastName  string `shon:"last"`
+				Ignored   string `shon:"-"`
+				private   string
+			}{
+				FirstName: "Jack",
+				LastName:  "Sparrow",
+				Ignored:   "x",
+				private:   "y",
+			},
+			want: []string{"[", "--first-name", "Jack", "--last", "Sparrow", "]"},
+		},
+		{desc: "struct/empty", give: struct{}{}, want: []string{"[--]"}},
+		{desc: "text", give: netip.MustParseAddr("::1"), want: []string{"::1"}},
+		{desc: "text/level", give: slog.Level(-2), want: []string{"DEBUG+2"}},
+		{desc: "text/escaped", give: *big.NewInt(-42), want: []string{"--", "-42"}},
+		{desc: "duration", give: 90 * time.Second, want: []string{"1m30s"}},
+		{desc: "duration/negative", give: -time.Second, want: []string{"--", "-1s"}},
+		{
+			desc: "time",
+			give: time.Date(2006, 1, 2, 15, 4, 5, 6, time.UTC),
+			want: []string{"2006-01-02T15:04:05.000000006Z"},
+		},
+		{
+			desc: "time/layout",
+			give: struct {
+				When []time.Time `shon:",layout=2006-01-02"`
+			}{When: []time.Time{time.Date(2006, 1, 2, 15, 4, 5, 6, time.UTC)}},
+			// Dates look numeric so they must be escaped.
+			want: []string{"[", "--when", "[", "--", "2006-01-02", "]", "]"},
+		},
+		{
+			desc: "text/map key",
+			give: map[netip.Addr]int{netip.MustParseAddr("10.0.0.1"): 1},
+			want: []string{"[", "--10.0.0.1", "1", "]"},
+		},
+		{
+			desc: "any",
+			give: []any{"a", 1, nil, []any{}, map[string]any{"x": true}},
+			want: []string{"[", "a", "1", "-n", "[]", "[", "--x", "-t", "]", "]"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := Marshal(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestMarshal_roundTrip(t *testing.T) {
+	t.Parallel()
+
+	type inner struct {
+		Name  string
+		Ports []uint16
+	}
+
+	type everything struct {
+		Bool      bool
+		Int       int
+		Int8      int8
+		Uint64    uint64
+		Float32   float32
+		Float64   float64
+		Complex   complex128
+		String    string
+		Strings   []string
+		Array     [2]int
+		Map       map[string]int
+		IntMap    map[int]string
+		Ptr       *int
+		NilPtr    *int
+		NilSlice  []int
+		Nested    inner
+		NestedPtr *inner
+		Items     []inner
+		Any       any
+		Renamed   string `shon:"other"`
+	}
+
+	tests := []struct {
+		desc string
+		give any
+	}{
+		{desc: "string/dashes", give: "--foo"},
+		{desc: "string/numeric", give: "+10"},
+		{desc: "strings", give: []string{"", "-", "--", "[", "]", "[]", "[--]", "-t", "1e3", "a b"}},
+		{desc: "nested slices", give: [][]string{{}, {"a"}, nil}},
+		{desc: "map/nested", give: map[string][]map[string]int{"a": {{"b": 1}, {}}}},
+		{desc: "float/exponent", give: 1e21},
+		{desc: "float/negative", give: -0.5},
+		{desc: "text", give: []netip.Addr{netip.MustParseAddr("::1"), {}}},
+		{desc: "text/pointer receiver", give: struct{ N big.Int }{N: *big.NewInt(-42)}},
+		{desc: "text/map key", give: map[slog.Level]bool{slog.LevelWarn: true, -8: false}},
+		{desc: "durations", give: []time.Duration{0, time.Nanosecond, -time.Hour}},
+		{
+			desc: "times",
+			give: struct {
+				At   time.Time
+				Date time.Time `shon:",layout=2006-01-02"`
+			}{
+				At:   time.Date(2006, 1, 2, 15, 4, 5, 6, time.UTC),
+				Date: time.Date(2006, 1, 2, 0, 0, 0, 0, time.UTC),
+			},
+		},
+		{
+			desc: "everything",
+			give: everything{
+				Bool:      true,
+				Int:       -42,
+				Int8:      -128,
+				Uint64:    math.MaxUint64,
+				Float32:   1.5,
+				Float64:   -2.25,
+				Complex:   complex(1, -2),
+				String:    "-- 10",
+				Strings:   []string{"--x", "10", "y"},
+				Array:     [2]int{1, 2},
+				Map:       map[string]int{"z": 1, "a": -1, "-x": 3},
+				IntMap:    map[int]string{1: "1", -2: "-2"},
+				Ptr:       ptrOf(7),
+				Nested:    inner{Name: "n", Ports: []uint16{80, 443}},
+				NestedPtr: &inner{Name: "p"},
+				Items:     []inner{{Name: "a"}, {Name: "b", Ports: []uint16{}}},
+				Any:       map[string]any{"k": []any{"v", true, nil}},
+				Renamed:   "renamed",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := Marshal(tt.give)
+			require.NoError(t, err)
+
+			got := reflect.New(reflect.TypeOf(tt.give))
+			require.NoError(t, Parse(args, got.Interface()), "Parse(%q)", args)
+			assert.Equal(t, tt.give, got.Elem().Interface(), "Parse(%q)", args)
+		})
+	}
+}
+
+func TestMarshalObject(t *testing.T) {
+	t.Parallel()
+
+	type user struct {
+		FirstName string
+		LastName  string
+	}
+
+	type Common struct {
+		Verbose bool `shon:"verbose"`
+	}
+
+	tests := []struct {
+		desc string
+		give any
+		want []string
+	}{
+		{
+			desc: "struct",
+			give: user{FirstName: "Jack", LastName: "Sparrow"},
+			want: []string{"--first-name", "Jack", "--last-name", "Sparrow"},
+		},
+		{
+			desc: "embedded",
+			give: struct {
+				Common
+				Name string `shon:"name"`
+			}{Common: Common{Verbose: true}, Name: "foo"},
+			want: []string{"--verbose", "-t", "--name", "foo"},
+		},
+		{
+			desc: "nil embedded pointer",
+			give: struct {
+				*Common
+				Name string `shon:"name"`
+			}{Name: "foo"},
+			want: []string{"--name", "foo"},
+		},
+		{
+			desc: "remaining",
+			give: struct {
+				Name  string         `shon:"name"`
+				Extra map[string]any `shon:",remaining"`
+			}{Name: "foo", Extra: map[string]any{"b": 1, "a": "x"}},
+			want: []string{"--name", "foo", "--a", "x", "--b", "1"},
+		},
+		{
+			desc: "passthrough",
+			give: struct {
+				Name string   `shon:"name"`
+				Rest []string `shon:",passthrough"`
+			}{Name: "foo", Rest: []string{"--x", "[", "1", "]", "--y=2"}},
+			want: []string{"--name", "foo", "--x", "[", "1", "]", "--y=2"},
+		},
+		{
+			desc: "positional",
+			give: struct {
+				Name  string   `shon:"name"`
+				Src   string   `shon:"src,arg"`
+				Files []string `shon:"files,args"`
+			}{Name: "foo", Src: "a", Files: []string{"b"}},
+			want: []string{"--name", "foo", "a", "b"},
+		},
+		{
+			desc: "positional/escaped",
+			give: struct {
+				Count int      `shon:"count,arg"`
+				Files []string `shon:"files,args"`
+			}{Count: -1, Files: []string{"a", "--b", "1", "[]"}},
+			want: []string{"-1", "a", "--", "--b", "1", "[]"},
+		},
+		{
+			desc: "positional/composite",
+			give: struct {
+				Ok    bool           `shon:"ok,arg"`
+				Pairs map[string]int `shon:"pairs,arg"`
+			}{Ok: true, Pairs: map[string]int{"a": 1}},
+			want: []string{"-t", "[", "--a", "1", "]"},
+		},
+		{
+			desc: "empty remaining",
+			give: struct {
+				Extra map[string]any `shon:",remaining"`
+			}{},
+		},
+		{
+			desc: "only nil embedded pointer",
+			give: struct{ *Common }{},
+		},
+		{
+			desc: "struct pointer",
+			give: &user{FirstName: "Jack"},
+			want: []string{"--first-name", "Jack", "--last-name", ""},
+		},
+		{desc: "nil pointer", give: (*user)(nil)},
+		{desc: "empty struct", give: struct{}{}},
+		{
+			desc: "map",
+			give: map[string]any{"items": []any{1, 2}},
+			want: []string{"--items", "[", "1", "2", "]"},
+		},
+		{desc: "empty map", give: map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := MarshalObject(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			// Must round-trip through ParseObject.
+			dst := reflect.New(reflect.TypeOf(tt.give))
+			require.NoError(t, ParseObject(got, dst.Interface()), "ParseObject(%q)", got)
+			if reflect.TypeOf(tt.give).Kind() == reflect.Struct {
+				assert.Equal(t, tt.give, dst.Elem().Interface(), "ParseObject(%q)", got)
+			}
+		})
+	}
+}
+
+func TestMarshalObject_notObject(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    any
+		wantErr string
+	}{
+		{"slice", []string{"foo"}, "expected a struct or map, got []string"},
+		{"time", time.Time{}, "time.Time does not encode as an object"},
+		{"text marshaler", &netip.Addr{}, "netip.Addr does not encode as an object"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := MarshalObject(tt.give)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestMarshalObject_positionalAfterEscape(t *testing.T) {
+	t.Parallel()
+
+	give := struct {
+		Name string `shon:"name,arg"`
+		Ok   bool   `shon:"ok,arg"`
+	}{Name: "--x", Ok: true}
+	_, err := MarshalObject(give)
+	assert.EqualError(t, err, `field ok: cannot encode "-t" after an argument that starts with '--'`)
+}
+
+func TestMarshal_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    any
+		wantErr string
+	}{
+		{desc: "chan", give: make(chan int), wantErr: "unsupported type chan int"},
+		{desc: "func field", give: struct{ F func() }{}, wantErr: "unsupported type func()"},
+		{desc: "any/chan", give: []any{make(chan int)}, wantErr: "unsupported type chan int"},
+		{desc: "float/nan", give: math.NaN(), wantErr: "unsupported value NaN"},
+		{desc: "float/inf", give: math.Inf(-1), wantErr: "unsupported value -Inf"},
+		{desc: "complex/inf", give: complex(math.Inf(1), 0), wantErr: "unsupported value"},
+		{desc: "map/bool key", give: map[bool]int{}, wantErr: "unsupported map key type bool"},
+		{desc: "map/pointer key", give: map[*int]int{}, wantErr: "unsupported map key type *int"},
+		{
+			desc:    "text/error",
+			give:    failingText{},
+			wantErr: "marshal shon.failingText: great sadness",
+		},
+		{
+			desc:    "text/key error",
+			give:    map[failingText]int{{}: 1},
+			wantErr: "great sadness",
+		},
+		{desc: "map/empty key", give: map[string]int{"": 1}, wantErr: "key must not be empty"},
+		{desc: "map/key with =", give: map[string]int{"a=b": 1}, wantErr: `"a=b": key must not contain '='`},
+		{
+			desc: "struct/positional",
+			give: struct {
+				Src string `shon:"src,arg"`
+			}{Src: "a"},
+			wantErr: "field src: positional arguments can only be encoded with MarshalObject",
+		},
+		{
+			desc:    "struct/bad field value",
+			give:    struct{ Items []float64 }{Items: []float64{math.Inf(1)}},
+			wantErr: "field items: unsupported value +Inf",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Marshal(tt.give)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNeedsEscape(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want bool
+	}{
+		{"", false},
+		{"foo", false},
+		{"[a", false},
+		{"a]", false},
+		{"10", true},
+		{"+10", true},
+		{"-", true},
+		{"-foo", true},
+		{"--foo", true},
+		{"[", true},
+		{"]", true},
+		{"[]", true},
+		{"[--]", true},
+	}
+
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, needsEscape(tt.give))
+		})
+	}
+}
+
+type failingText struct{}
+
+func (failingText) MarshalText() ([]byte, error) {
+	return nil, errors.New("great sadness")
+}
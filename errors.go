@@ -0,0 +1,191 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// SyntaxError is returned by [Parse] and friends
+// when the input is not valid SHON.
+//
+// Use [errors.As] to check for it.
+type SyntaxError struct {
+	// Index of the argument that caused the error.
+	//
+	// If the input ended unexpectedly,
+	// this is the number of arguments in the input.
+	// If a '[' was never closed, this is the index of that '['.
+	Index int
+
+	// Token is the argument at Index,
+	// or an empty string if the input ended unexpectedly.
+	Token string
+
+	// Msg describes the problem.
+	Msg string
+
+	// Suggestions holds alternatives for Token
+	// that the user may have meant, if any.
+	// For example, "-t" for "-true",
+	// or "-- -true" to pass "-true" as a string.
+	Suggestions []string
+
+	eof bool // whether the input ended unexpectedly
+}
+
+func (e *SyntaxError) Error() string {
+	if e.eof {
+		return "unexpected end of input: " + e.Msg
+	}
+	return fmt.Sprintf("argument %d: %s", e.Index, e.Msg) + didYouMean(e.Suggestions)
+}
+
+// DecodeError is returned by [Parse] and friends
+// when a valid SHON value cannot be decoded into the target Go type.
+//
+// Use [errors.As] to check for it.
+type DecodeError struct {
+	// Index of the argument where the offending value starts.
+	// For errors about object keys, this is the index of the key.
+	Index int
+
+	// Token is the argument at Index.
+	Token string
+
+	// Path to the offending value from the top-level value,
+	// with object keys prefixed by '.' and array indexes in brackets.
+	// For example:
+	//
+	//	.servers[2].port
+	//
+	// Path is empty for the top-level value.
+	Path string
+
+	// Type is the Go type that the value was being decoded into.
+	// This is nil if the type is not known,
+	// e.g. for errors reported by a [Decoder] inside an [Unmarshaler].
+	Type reflect.Type
+
+	// Kind is the kind of the SHON value.
+	Kind Kind
+
+	// Err is the underlying error.
+	Err error
+
+	// Suggestions holds the names of known fields
+	// closest to the offending key
+	// for errors about unknown object keys.
+	Suggestions []string
+}
+
+func (e *DecodeError) Error() string {
+	if len(e.Path) == 0 {
+		return fmt.Sprintf("argument %d: %v", e.Index, e.Err) + didYouMean(e.Suggestions)
+	}
+	return fmt.Sprintf("argument %d (%v): %v", e.Index, e.Path, e.Err) + didYouMean(e.Suggestions)
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// MissingFieldsError reports that an object did not specify
+// fields marked as required with the shon:",required" tag option.
+//
+// It is wrapped in a [*DecodeError] that points to the object.
+type MissingFieldsError struct {
+	// Paths to the missing fields from the top-level value,
+	// in the order they were declared.
+	// See DecodeError.Path for the format.
+	Paths []string
+}
+
+func (e *MissingFieldsError) Error() string {
+	if len(e.Paths) == 1 {
+		return "missing required field " + e.Paths[0]
+	}
+	return "missing required fields: " + strings.Join(e.Paths, ", ")
+}
+
+// syntaxErrorf builds a SyntaxError for argument arg at index pos.
+func syntaxErrorf(pos int, arg, format string, args ...any) error {
+	return &SyntaxError{
+		Index: pos,
+		Token: arg,
+		Msg:   fmt.Sprintf(format, args...),
+	}
+}
+
+// eofError builds a SyntaxError for an unexpected end of input.
+func (p *parser) eofError(msg string) error {
+	return &SyntaxError{Index: p.index(), Msg: msg, eof: true}
+}
+
+// withKey returns a copy of ctx for decoding the value at the given
+// object key.
+func (ctx decodeCtx) withKey(key string) decodeCtx {
+	ctx.Path += "." + key
+	ctx.Into = reflect.Value{}
+	return ctx
+}
+
+// withIndex returns a copy of ctx for decoding the value at the given
+// array index.
+func (ctx decodeCtx) withIndex(idx int) decodeCtx {
+	ctx.Path += "[" + strconv.Itoa(idx) + "]"
+	ctx.Into = reflect.Value{}
+	return ctx
+}
+
+// errorf builds a DecodeError for value v that could not be decoded
+// into type t.
+func (ctx decodeCtx) errorf(t reflect.Type, v value, format string, args ...any) error {
+	return ctx.wrapError(t, v, fmt.Errorf(format, args...))
+}
+
+// wrapError wraps err into a DecodeError for value v
+// that could not be decoded into type t.
+func (ctx decodeCtx) wrapError(t reflect.Type, v value, err error) error {
+	return ctx.newError(t, v, err)
+}
+
+// newError is a variant of wrapError that returns a *DecodeError.
+func (ctx decodeCtx) newError(t reflect.Type, v value, err error) *DecodeError {
+	var tok string
+	if v.pos < len(ctx.Args) {
+		tok = ctx.Args[v.pos]
+	}
+
+	return &DecodeError{
+		Index: v.pos,
+		Token: tok,
+		Path:  ctx.Path,
+		Type:  t,
+		Kind:  Kind(v.t),
+		Err:   err,
+	}
+}
+
+// unknownKeyError builds a DecodeError for the object key
+// associated with value v, which does not match any of the given
+// known keys.
+func (ctx decodeCtx) unknownKeyError(t reflect.Type, v value, key string, known []string) error {
+	v.pos = v.kpos
+	err := ctx.newError(t, v, fmt.Errorf("unknown field %q", key))
+	err.Suggestions = suggest(key, known)
+	return err
+}
+
+// joinErrors combines errs into one error.
+// A single error is returned as-is
+// so that callers may inspect it directly.
+func joinErrors(errs []error) error {
+	if len(errs) == 1 {
+		return errs[0]
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,683 @@
+package shon
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSyntaxError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    []string
+		want    *SyntaxError
+		wantMsg string
+	}{
+		{
+			desc:    "empty",
+			want:    &SyntaxError{Index: 0, Msg: "expected a value", eof: true},
+			wantMsg: "unexpected end of input: expected a value",
+		},
+		{
+			desc: "unexpected flag",
+			give: []string{"[", "a", "-x", "]"},
+			want: &SyntaxError{
+				Index:       2,
+				Token:       "-x",
+				Msg:         `unexpected flag "-x"`,
+				Suggestions: []string{"-- -x"},
+			},
+			wantMsg: `argument 2: unexpected flag "-x"; did you mean "-- -x"?`,
+		},
+		{
+			desc: "unexpected boolean flag",
+			give: []string{"[", "-true", "]"},
+			want: &SyntaxError{
+				Index:       1,
+				Token:       "-true",
+				Msg:         `unexpected flag "-true"`,
+				Suggestions: []string{"-t", "-- -true"},
+			},
+			wantMsg: `argument 1: unexpected flag "-true"; did you mean "-t" or "-- -true"?`,
+		},
+		{
+			desc:    "unexpected close",
+			give:    []string{"[", "--a", "]", "]"},
+			want:    &SyntaxError{Index: 2, Token: "]", Msg: `expected a value, got "]"`},
+			wantMsg: `argument 2: expected a value, got "]"`,
+		},
+		{
+			desc:    "missing string",
+			give:    []string{"[", "a", "--"},
+			want:    &SyntaxError{Index: 3, Msg: "expected a string", eof: true},
+			wantMsg: "unexpected end of input: expected a string",
+		},
+		{
+			desc:    "unclosed array",
+			give:    []string{"[", "a", "[", "b", "]"},
+			want:    &SyntaxError{Index: 0, Token: "[", Msg: "unclosed '['"},
+			wantMsg: "argument 0: unclosed '['",
+		},
+		{
+			desc:    "unclosed object",
+			give:    []string{"[", "[", "--a", "b", "]", "[", "--c", "d"},
+			want:    &SyntaxError{Index: 5, Token: "[", Msg: "unclosed '['"},
+			wantMsg: "argument 5: unclosed '['",
+		},
+		{
+			desc: "bad key",
+			give: []string{"[", "--a", "1", "b", "]"},
+			want: &SyntaxError{Index: 3, Token: "b", Msg: `expected object key, got "b"`},
+		},
+		{
+			desc: "trailing",
+			give: []string{"a", "b", "c"},
+			want: &SyntaxError{Index: 1, Token: "b", Msg: `unexpected arguments: ["b" "c"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var v any
+			err := Parse(tt.give, &v)
+
+			var got *SyntaxError
+			require.ErrorAs(t, err, &got)
+			assert.Equal(t, tt.want, got)
+			if tt.wantMsg != "" {
+				assert.EqualError(t, err, tt.wantMsg)
+			}
+		})
+	}
+}
+
+func TestParseObject_syntaxErrors(t *testing.T) {
+	t.Parallel()
+
+	var v map[string]any
+	err := ParseObject([]string{"--a", "b", "]"}, &v)
+
+	var got *SyntaxError
+	require.ErrorAs(t, err, &got)
+	assert.Equal(t, &SyntaxError{Index: 2, Token: "]", Msg: `expected object key, got "]"`}, got)
+}
+
+func TestParseObject_positionalErrors(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Verbose bool   `shon:"verbose"`
+		Count   int    `shon:"count,arg"`
+		Name    string `shon:"name,arg"`
+	}
+
+	tests := []struct {
+		desc    string
+		give    []string
+		wantErr string
+	}{
+		{
+			desc:    "too many",
+			give:    []string{"1", "foo", "bar"},
+			wantErr: `argument 2: unexpected positional argument "bar"`,
+		},
+		{
+			desc:    "bad value",
+			give:    []string{"x"},
+			wantErr: `argument 0 (.count): bad int: strconv.ParseInt: parsing "x": invalid syntax`,
+		},
+		{
+			desc:    "unexpected flag",
+			give:    []string{"--verbose", "-t", "-x"},
+			wantErr: `argument 2: unexpected flag "-x"; did you mean "-- -x"?`,
+		},
+		{
+			desc:    "in brackets",
+			give:    []string{"--verbose", "[", "1", "]"},
+			wantErr: `argument 1 (.verbose): expected bool, got array`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got options
+			err := ParseObject(tt.give, &got)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Child options `shon:"child"`
+		}
+		err := ParseObject([]string{"--child", "[", "--verbose", "-t", "1", "]"}, &got)
+		assert.EqualError(t, err, `argument 4: expected object key, got "1"`)
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		t.Parallel()
+
+		var got options
+		err := ParseObject([]string{"x", "foo", "bar", "--verbose", "-t"}, &got, AllErrors(true))
+		require.Error(t, err)
+		assert.Equal(t, []string{
+			`argument 0 (.count): bad int: strconv.ParseInt: parsing "x": invalid syntax`,
+			`argument 2: unexpected positional argument "bar"`,
+		}, strings.Split(err.Error(), "\n"))
+	})
+}
+
+func TestDecodeError(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string
+		Port uint16
+	}
+
+	type config struct {
+		Servers []server
+		Ports   [2]int
+		Labels  map[int]string
+		Extra   any
+		Version testVersion
+		Kinds   []testKind
+	}
+
+	tests := []struct {
+		desc    string
+		give    []string
+		want    *DecodeError
+		wantMsg string
+	}{
+		{
+			desc: "nested field",
+			give: []string{
+				"--servers", "[",
+				"[", "--host", "a", "--port", "80", "]",
+				"[", "--host", "b", "--port", "x", "]",
+				"]",
+			},
+			want: &DecodeError{
+				Index: 12,
+				Token: "x",
+				Path:  ".servers[1].port",
+				Type:  reflect.TypeOf(uint16(0)),
+				Kind:  ScalarKind,
+			},
+			wantMsg: `argument 12 (.servers[1].port): bad uint16: strconv.ParseUint: parsing "x": invalid syntax`,
+		},
+		{
+			desc: "type mismatch",
+			give: []string{"--servers", "[", "[", "--host", "[]", "]", "]"},
+			want: &DecodeError{
+				Index: 4,
+				Token: "[]",
+				Path:  ".servers[0].host",
+				Type:  reflect.TypeOf(""),
+				Kind:  ArrayKind,
+			},
+			wantMsg: "argument 4 (.servers[0].host): expected string, got array",
+		},
+		{
+			desc: "inline value",
+			give: []string{"--servers", "[", "[", "--port=-t", "]", "]"},
+			want: &DecodeError{
+				Index: 3,
+				Token: "--port=-t",
+				Path:  ".servers[0].port",
+				Type:  reflect.TypeOf(uint16(0)),
+				Kind:  BoolKind,
+			},
+		},
+		{
+			desc: "unknown field",
+			give: []string{"--servers", "[", "[", "--hots", "a", "]", "]"},
+			want: &DecodeError{
+				Index:       3,
+				Token:       "--hots",
+				Path:        ".servers[0].hots",
+				Type:        reflect.TypeOf(server{}),
+				Kind:        ScalarKind,
+				Suggestions: []string{"host"},
+			},
+			wantMsg: `argument 3 (.servers[0].hots): unknown field "hots"; did you mean "host"?`,
+		},
+		{
+			desc: "unknown field no suggestions",
+			give: []string{"--servers", "[", "[", "--address", "a", "]", "]"},
+			want: &DecodeError{
+				Index: 3,
+				Token: "--address",
+				Path:  ".servers[0].address",
+				Type:  reflect.TypeOf(server{}),
+				Kind:  ScalarKind,
+			},
+			wantMsg: `argument 3 (.servers[0].address): unknown field "address"`,
+		},
+		{
+			desc: "too many values",
+			give: []string{"--ports", "[", "1", "2", "3", "]"},
+			want: &DecodeError{
+				Index: 4,
+				Token: "3",
+				Path:  ".ports[2]",
+				Type:  reflect.TypeOf([2]int{}),
+				Kind:  ScalarKind,
+			},
+		},
+		{
+			desc: "map key",
+			give: []string{"--labels", "[", "--1", "a", "--x", "b", "]"},
+			want: &DecodeError{
+				Index: 4,
+				Token: "--x",
+				Path:  ".labels.x",
+				Type:  reflect.TypeOf(0),
+				Kind:  ScalarKind,
+			},
+		},
+		{
+			desc: "any",
+			give: []string{"--extra", "[", "[", "--n", "1-2", "]", "]"},
+			want: &DecodeError{
+				Index: 4,
+				Token: "1-2",
+				Path:  ".extra[0].n",
+				Type:  reflect.TypeOf((*any)(nil)).Elem(),
+				Kind:  ScalarKind,
+			},
+		},
+		{
+			desc: "unmarshaler",
+			give: []string{"--version", "x"},
+			want: &DecodeError{
+				Index: 1,
+				Token: "x",
+				Path:  ".version",
+				Type:  reflect.TypeOf(testVersion{}),
+				Kind:  ScalarKind,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var v config
+			err := ParseObject(tt.give, &v)
+
+			if tt.wantMsg != "" {
+				assert.EqualError(t, err, tt.wantMsg)
+			}
+
+			var got *DecodeError
+			require.ErrorAs(t, err, &got)
+			assert.NotNil(t, got.Err)
+			got.Err = nil // not compared
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecodeError_unmarshaler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("plain error", func(t *testing.T) {
+		t.Parallel()
+
+		var v sadUnmarshaler
+		err := Parse([]string{"[", "[", "a", "]", "]"}, &v)
+		assert.ErrorIs(t, err, errSadness)
+
+		var got *DecodeError
+		require.ErrorAs(t, err, &got)
+		assert.Equal(t, "[0][0]", got.Path)
+		assert.Equal(t, 2, got.Index)
+		assert.Nil(t, got.Type)
+	})
+
+	t.Run("decode error", func(t *testing.T) {
+		t.Parallel()
+
+		var v sadUnmarshaler
+		err := Parse([]string{"[", "[", "-t", "--", "x", "]", "]"}, &v)
+
+		var got *DecodeError
+		require.ErrorAs(t, err, &got)
+		assert.Equal(t, "[0][1]", got.Path)
+		assert.Equal(t, 3, got.Index)
+		assert.Equal(t, reflect.TypeOf(true), got.Type)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+
+		var v sadUnmarshaler
+		err := Parse([]string{"[", "[", "-t", "-x", "]", "]"}, &v)
+
+		var got *SyntaxError
+		require.ErrorAs(t, err, &got)
+		assert.Equal(t, 3, got.Index)
+	})
+}
+
+func TestParse_allErrors(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string
+		Port uint16
+	}
+
+	type config struct {
+		Name    string
+		Servers []server
+		Ports   [2]int
+		Labels  map[int]string
+		Extra   any
+		Sad     sadUnmarshaler
+	}
+
+	tests := []struct {
+		desc      string
+		give      []string
+		wantPaths []string
+		wantIdx   []int
+	}{
+		{
+			desc: "unknown fields",
+			give: []string{
+				"--nmae", "foo",
+				"--servers", "[", "[", "--hots", "a", "--port", "80", "]", "]",
+				"--port", "[", "1", "]",
+			},
+			wantPaths: []string{".nmae", ".servers[0].hots", ".port"},
+			wantIdx:   []int{0, 5, 11},
+		},
+		{
+			desc: "bad values",
+			give: []string{
+				"--name", "[", "a", "]",
+				"--servers", "[",
+				"[", "--port", "x", "]",
+				"[", "--host", "-t", "--port", "-1", "]",
+				"]",
+				"--ports", "[", "1", "2", "3", "]",
+				"--labels", "[", "--a", "b", "--1", "[", "c", "]", "]",
+				"--extra", "[", "1", "1e1000", "]",
+				"--sad", "[", "[", "a", "]", "]",
+			},
+			wantPaths: []string{
+				".name",
+				".servers[0].port",
+				".servers[1].host",
+				".servers[1].port",
+				".ports[2]",
+				".labels.a",
+				".labels.1",
+				".extra[1]",
+				".sad[0][0]",
+			},
+			wantIdx: []int{1, 8, 12, 14, 21, 25, 28, 35, 40},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got config
+			err := ParseObject(tt.give, &got, AllErrors(true))
+			require.Error(t, err)
+
+			joined, ok := err.(interface{ Unwrap() []error })
+			require.True(t, ok, "expected a joined error, got %T", err)
+
+			var (
+				paths []string
+				idxs  []int
+			)
+			for _, err := range joined.Unwrap() {
+				var decodeErr *DecodeError
+				require.ErrorAs(t, err, &decodeErr)
+				paths = append(paths, decodeErr.Path)
+				idxs = append(idxs, decodeErr.Index)
+			}
+			assert.Equal(t, tt.wantPaths, paths)
+			assert.Equal(t, tt.wantIdx, idxs)
+		})
+	}
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+
+		give := []string{"--nmae", "foo", "--servers", "[", "-x", "]"}
+
+		var got config
+		err := ParseObject(give, &got, AllErrors(true))
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, ".nmae", decodeErr.Path)
+
+		var syntaxErr *SyntaxError
+		require.ErrorAs(t, err, &syntaxErr)
+		assert.Equal(t, 4, syntaxErr.Index)
+	})
+
+	t.Run("no errors", func(t *testing.T) {
+		t.Parallel()
+
+		var got config
+		err := ParseObject([]string{"--name", "foo", "--ports", "[", "1", "]"}, &got, AllErrors(true))
+		require.NoError(t, err)
+		assert.Equal(t, config{Name: "foo", Ports: [2]int{1, 0}}, got)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		var got config
+		err := ParseObject([]string{"--nmae", "foo", "--extra", "1e1000"}, &got)
+
+		var decodeErr *DecodeError
+		require.ErrorAs(t, err, &decodeErr)
+		assert.Equal(t, ".nmae", decodeErr.Path)
+		assert.NotContains(t, err.Error(), ".extra")
+	})
+}
+
+func TestParse_errorTypes(t *testing.T) {
+	t.Parallel()
+
+	type config struct{ Port int }
+
+	t.Run("decode error", func(t *testing.T) {
+		t.Parallel()
+
+		var got config
+		err := ParseObject([]string{"--port", "x"}, &got)
+		_, ok := err.(*DecodeError)
+		assert.True(t, ok, "expected *DecodeError, got %T", err)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+
+		var got []int
+		err := Parse([]string{"[", "1"}, &got)
+		_, ok := err.(*SyntaxError)
+		assert.True(t, ok, "expected *SyntaxError, got %T", err)
+	})
+
+	t.Run("single error with AllErrors", func(t *testing.T) {
+		t.Parallel()
+
+		var got config
+		err := ParseObject([]string{"--port", "x"}, &got, AllErrors(true))
+		_, ok := err.(*DecodeError)
+		assert.True(t, ok, "expected *DecodeError, got %T", err)
+	})
+
+	t.Run("command", func(t *testing.T) {
+		t.Parallel()
+
+		var got testRootCmd
+		_, err := ParseCommand([]string{"biuld"}, &got)
+		_, ok := err.(*DecodeError)
+		assert.True(t, ok, "expected *DecodeError, got %T", err)
+	})
+}
+
+func TestParse_required(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `shon:"host,required"`
+		Port uint16 `shon:"port,required"`
+		Name string
+	}
+
+	type config struct {
+		Env     string   `shon:"env,required"`
+		Servers []server `shon:"servers"`
+		Primary *server  `shon:"primary"`
+	}
+
+	tests := []struct {
+		desc      string
+		give      []string
+		want      config
+		wantIndex int
+		wantPath  string
+		wantPaths []string
+		wantMsg   string
+	}{
+		{
+			desc: "all present",
+			give: []string{"--env", "prod", "--primary", "[", "--host", "a", "--port", "80", "]"},
+			want: config{
+				Env:     "prod",
+				Primary: &server{Host: "a", Port: 80},
+			},
+		},
+		{
+			desc: "nested struct absent",
+			give: []string{"--env", "prod"},
+			want: config{Env: "prod"},
+		},
+		{
+			desc:      "top level",
+			give:      []string{"--servers", "[]"},
+			wantIndex: 0,
+			wantPaths: []string{".env"},
+			wantMsg:   "argument 0: missing required field .env",
+		},
+		{
+			desc: "nested",
+			give: []string{
+				"--env", "prod",
+				"--servers", "[",
+				"[", "--host", "a", "--port", "80", "]",
+				"[", "--name", "b", "]",
+				"]",
+			},
+			wantIndex: 10,
+			wantPath:  ".servers[1]",
+			wantPaths: []string{".servers[1].host", ".servers[1].port"},
+			wantMsg:   "argument 10 (.servers[1]): missing required fields: .servers[1].host, .servers[1].port",
+		},
+		{
+			desc:      "bad value is not missing",
+			give:      []string{"--env", "prod", "--primary", "[", "--host", "a", "--port", "x", "]"},
+			wantIndex: 7,
+			wantPath:  ".primary.port",
+			wantMsg:   `argument 7 (.primary.port): bad uint16: strconv.ParseUint: parsing "x": invalid syntax`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got config
+			err := ParseObject(tt.give, &got)
+			if tt.wantMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+
+			require.EqualError(t, err, tt.wantMsg)
+
+			var decodeErr *DecodeError
+			require.ErrorAs(t, err, &decodeErr)
+			assert.Equal(t, tt.wantIndex, decodeErr.Index)
+			assert.Equal(t, tt.wantPath, decodeErr.Path)
+
+			var missingErr *MissingFieldsError
+			if len(tt.wantPaths) > 0 {
+				require.ErrorAs(t, err, &missingErr)
+				assert.Equal(t, tt.wantPaths, missingErr.Paths)
+			} else {
+				assert.False(t, errors.As(err, &missingErr))
+			}
+		})
+	}
+
+	t.Run("all errors", func(t *testing.T) {
+		t.Parallel()
+
+		give := []string{
+			"--servers", "[",
+			"[", "--host", "a", "]",
+			"[", "--port", "80", "]",
+			"]",
+		}
+
+		var got config
+		err := ParseObject(give, &got, AllErrors(true))
+		assert.EqualError(t, err, strings.Join([]string{
+			"argument 2 (.servers[0]): missing required field .servers[0].port",
+			"argument 6 (.servers[1]): missing required field .servers[1].host",
+			"argument 0: missing required field .env",
+		}, "\n"))
+	})
+}
+
+var errSadness = errors.New("great sadness")
+
+// sadUnmarshaler expects arrays of arrays of booleans.
+// It fails with errSadness for scalars.
+type sadUnmarshaler struct{}
+
+func (*sadUnmarshaler) UnmarshalSHON(d *Decoder) error {
+	return d.Array(func(d *Decoder) error {
+		return d.Array(func(d *Decoder) error {
+			if d.Kind() == ScalarKind {
+				return errSadness
+			}
+			var b bool
+			return d.Decode(&b)
+		})
+	})
+}
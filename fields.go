@@ -0,0 +1,219 @@
+package shon
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+)
+
+// fieldInfo is a field of a struct that is addressable by name.
+// It may be promoted from an embedded struct.
+type fieldInfo struct {
+	field reflect.StructField
+	tag   fieldTag
+
+	// Path to the field from the top-level struct.
+	// See reflect.Value.FieldByIndex.
+	index []int
+
+	// List of names this field accepts.
+	// See fieldTag.names.
+	names []string
+}
+
+// tagged reports whether the field's name was set explicitly.
+func (f *fieldInfo) tagged() bool {
+	return len(f.tag.name) > 0
+}
+
+// visibleFields returns the fields of struct type t
+// that are addressable by name, in declaration order.
+//
+// The fields of embedded structs and pointers to structs
+// are promoted to the top level
+// unless the embedded field has a name in its tag.
+// Other struct fields are promoted with the inline tag option.
+//
+// Name conflicts are resolved by the same rules as encoding/json:
+// a field at a shallower depth hides those at deeper depths,
+// and a field with a name in its tag hides untagged fields at the same depth.
+// Otherwise, conflicting fields are all dropped.
+//
+// The returned fields include at most one field
+// with the remaining or passthrough tag options:
+// the one at the shallowest depth.
+// Multiple such fields at the same depth are an error.
+func visibleFields(t reflect.Type) ([]fieldInfo, error) {
+	type embedded struct {
+		t     reflect.Type
+		index []int
+	}
+
+	var (
+		fields    []fieldInfo
+		remaining []fieldInfo // fields that receive unknown keys
+		next      = []embedded{{t: t}}
+		visited   = make(map[reflect.Type]struct{})
+	)
+	for len(next) > 0 {
+		current := next
+		next = nil
+
+		// A type embedded more than once at the same depth
+		// is visited as many times so that its fields conflict.
+		for _, e := range current {
+			if _, ok := visited[e.t]; ok {
+				continue
+			}
+
+			for i := 0; i < e.t.NumField(); i++ {
+				f := e.t.Field(i)
+				ft := f.Type
+				if ft.Kind() == reflect.Pointer {
+					ft = ft.Elem()
+				}
+
+				if !f.IsExported() {
+					// Exported fields of unexported embedded structs
+					// are still visible, but we can't allocate
+					// unexported embedded pointers.
+					if !f.Anonymous || f.Type.Kind() != reflect.Struct {
+						continue
+					}
+				}
+
+				tag, err := parseFieldTag(f)
+				if err != nil {
+					return nil, err
+				}
+				if tag.skip {
+					continue
+				}
+
+				index := append(slices.Clip(e.index), i)
+				if tag.inline || (f.Anonymous && len(tag.name) == 0 && flattens(ft)) {
+					if !flattens(ft) {
+						return nil, fmt.Errorf("field %v: cannot inline %v", f.Name, f.Type)
+					}
+					next = append(next, embedded{t: ft, index: index})
+					continue
+				}
+
+				if !f.IsExported() {
+					continue
+				}
+
+				info := fieldInfo{
+					field: f,
+					tag:   tag,
+					index: index,
+					names: tag.names(f),
+				}
+				switch {
+				case tag.remaining && (f.Type.Kind() != reflect.Map || f.Type.Key().Kind() != reflect.String):
+					return nil, fmt.Errorf("field %v: remaining requires a map with string keys, got %v", f.Name, f.Type)
+				case tag.passthrough && f.Type != _stringsType:
+					return nil, fmt.Errorf("field %v: passthrough requires []string, got %v", f.Name, f.Type)
+				case tag.cmd && ft.Kind() != reflect.Struct:
+					return nil, fmt.Errorf("field %v: cmd requires a struct or pointer to struct, got %v", f.Name, f.Type)
+				}
+
+				if tag.catchAll() {
+					remaining = append(remaining, info)
+				} else {
+					fields = append(fields, info)
+				}
+			}
+		}
+
+		for _, e := range current {
+			visited[e.t] = struct{}{}
+		}
+	}
+
+	// Sort by name, then depth, then tagged fields first
+	// so that the dominant field for each name is first.
+	sort.SliceStable(fields, func(i, j int) bool {
+		fi, fj := &fields[i], &fields[j]
+		if fi.names[0] != fj.names[0] {
+			return fi.names[0] < fj.names[0]
+		}
+		if len(fi.index) != len(fj.index) {
+			return len(fi.index) < len(fj.index)
+		}
+		return fi.tagged() && !fj.tagged()
+	})
+
+	visible := fields[:0]
+	for len(fields) > 0 {
+		n := 1
+		for n < len(fields) && fields[n].names[0] == fields[0].names[0] {
+			n++
+		}
+
+		group := fields[:n]
+		fields = fields[n:]
+		if len(group) > 1 &&
+			len(group[0].index) == len(group[1].index) &&
+			group[0].tagged() == group[1].tagged() {
+			continue // conflict
+		}
+		visible = append(visible, group[0])
+	}
+
+	// Fields were found in breadth-first order,
+	// so the first remaining field is the shallowest.
+	if len(remaining) > 0 {
+		if len(remaining) > 1 && len(remaining[0].index) == len(remaining[1].index) {
+			return nil, fmt.Errorf("fields %v and %v: only one field may receive unknown keys",
+				remaining[0].field.Name, remaining[1].field.Name)
+		}
+		visible = append(visible, remaining[0])
+	}
+
+	// Restore declaration order.
+	sort.Slice(visible, func(i, j int) bool {
+		return slices.Compare(visible[i].index, visible[j].index) < 0
+	})
+	return visible, nil
+}
+
+// flattens reports whether embedded fields of type t
+// should have their fields promoted by default.
+// Types with custom decoding are kept as-is.
+func flattens(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || t == _timeType {
+		return false
+	}
+	pt := reflect.PointerTo(t)
+	return !pt.Implements(_unmarshalerType) && !pt.Implements(_textUnmarshalerType)
+}
+
+// fieldByIndex returns the nested field of struct v at index.
+//
+// If alloc is set, nil pointers to embedded structs along the way
+// are allocated.
+// Otherwise, an invalid value is returned if one is found.
+func fieldByIndex(v reflect.Value, index []int, alloc bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !alloc {
+					return reflect.Value{}
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v
+}
+
+// isZeroField reports whether the nested field of struct v at index
+// is zero or unreachable due to a nil pointer.
+func isZeroField(v reflect.Value, index []int) bool {
+	fv := fieldByIndex(v, index, false)
+	return !fv.IsValid() || fv.IsZero()
+}
@@ -0,0 +1,305 @@
+package shon
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVisibleFields(t *testing.T) {
+	t.Parallel()
+
+	type Common struct {
+		Verbose bool
+		Name    string
+	}
+
+	type Other struct {
+		Name  string
+		Depth int
+	}
+
+	type Tagged struct {
+		Name string `shon:"name"`
+	}
+
+	type unexported struct {
+		Hidden  int
+		private int
+	}
+
+	type Deep struct {
+		Common
+	}
+
+	tests := []struct {
+		desc string
+		give any
+		want map[string][]int // canonical name => index
+	}{
+		{
+			desc: "embedded",
+			give: struct {
+				Common
+				Extra int
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"name":    {0, 1},
+				"extra":   {1},
+			},
+		},
+		{
+			desc: "embedded pointer",
+			give: struct {
+				*Common
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"name":    {0, 1},
+			},
+		},
+		{
+			desc: "shallower wins",
+			give: struct {
+				Common
+				Name int
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"name":    {1},
+			},
+		},
+		{
+			desc: "conflict at same depth",
+			give: struct {
+				Common
+				Other
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"depth":   {1, 1},
+			},
+		},
+		{
+			desc: "tagged wins at same depth",
+			give: struct {
+				Common
+				Tagged
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"name":    {1, 0},
+			},
+		},
+		{
+			desc: "deeper",
+			give: struct {
+				Deep
+				Other
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0, 0},
+				"name":    {1, 0},
+				"depth":   {1, 1},
+			},
+		},
+		{
+			desc: "named tag",
+			give: struct {
+				Common `shon:"common"`
+			}{},
+			want: map[string][]int{
+				"common": {0},
+			},
+		},
+		{
+			desc: "inline",
+			give: struct {
+				Opts  Common `shon:",inline"`
+				Other *Other `shon:",inline"`
+			}{},
+			want: map[string][]int{
+				"verbose": {0, 0},
+				"depth":   {1, 1},
+			},
+		},
+		{
+			desc: "unexported embedded",
+			give: struct {
+				unexported
+			}{},
+			want: map[string][]int{
+				"hidden": {0, 0},
+			},
+		},
+		{
+			desc: "unexported embedded pointer",
+			give: struct {
+				*unexported
+			}{},
+			want: map[string][]int{},
+		},
+		{
+			desc: "custom decoding",
+			give: struct {
+				time.Time
+			}{},
+			want: map[string][]int{
+				"time": {0},
+			},
+		},
+		{
+			desc: "remaining",
+			give: struct {
+				Name  string
+				Extra map[string]any `shon:",remaining"`
+			}{},
+			want: map[string][]int{
+				"name":  {0},
+				"extra": {1},
+			},
+		},
+		{
+			desc: "shallower remaining wins",
+			give: struct {
+				Plugin struct {
+					Extra map[string]any `shon:",remaining"`
+				} `shon:",inline"`
+				Rest map[string]string `shon:",remaining"`
+			}{},
+			want: map[string][]int{
+				"rest": {1},
+			},
+		},
+		{
+			desc: "skipped",
+			give: struct {
+				Common `shon:"-"`
+			}{},
+			want: map[string][]int{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			fields, err := visibleFields(reflect.TypeOf(tt.give))
+			require.NoError(t, err)
+
+			got := make(map[string][]int)
+			for _, f := range fields {
+				got[f.names[0]] = f.index
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestVisibleFields_order(t *testing.T) {
+	t.Parallel()
+
+	type Common struct{ B, D int }
+
+	type config struct {
+		A int
+		Common
+		C int
+		E int
+	}
+
+	fields, err := visibleFields(reflect.TypeOf(config{}))
+	require.NoError(t, err)
+
+	var names []string
+	for _, f := range fields {
+		names = append(names, f.names[0])
+	}
+	assert.Equal(t, []string{"a", "b", "d", "c", "e"}, names)
+}
+
+func TestVisibleFields_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    any
+		wantErr string
+	}{
+		{
+			desc: "inline non-struct",
+			give: struct {
+				Foo int `shon:",inline"`
+			}{},
+			wantErr: "field Foo: cannot inline int",
+		},
+		{
+			desc: "inline time",
+			give: struct {
+				When time.Time `shon:",inline"`
+			}{},
+			wantErr: "field When: cannot inline time.Time",
+		},
+		{
+			desc: "remaining non-map",
+			give: struct {
+				Extra []string `shon:",remaining"`
+			}{},
+			wantErr: "field Extra: remaining requires a map with string keys, got []string",
+		},
+		{
+			desc: "remaining int keys",
+			give: struct {
+				Extra map[int]string `shon:",remaining"`
+			}{},
+			wantErr: "field Extra: remaining requires a map with string keys, got map[int]string",
+		},
+		{
+			desc: "passthrough non-strings",
+			give: struct {
+				Rest []any `shon:",passthrough"`
+			}{},
+			wantErr: "field Rest: passthrough requires []string, got []interface {}",
+		},
+		{
+			desc: "remaining and passthrough",
+			give: struct {
+				Extra map[string]any `shon:",remaining"`
+				Rest  []string       `shon:",passthrough"`
+			}{},
+			wantErr: "fields Extra and Rest: only one field may receive unknown keys",
+		},
+		{
+			desc: "multiple remaining",
+			give: struct {
+				A map[string]any `shon:",remaining"`
+				B map[string]any `shon:",remaining"`
+			}{},
+			wantErr: "fields A and B: only one field may receive unknown keys",
+		},
+		{
+			desc: "bad tag in embedded",
+			give: struct {
+				Bad struct {
+					Foo int `shon:",bar"`
+				} `shon:",inline"`
+			}{},
+			wantErr: `field Foo: unknown tag option "bar"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := visibleFields(reflect.TypeOf(tt.give))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
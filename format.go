@@ -0,0 +1,129 @@
+package shon
+
+import (
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// FormatError renders err for display to a user,
+// pointing out the offending argument in args with a caret.
+//
+// args must be the arguments that produced err.
+// For example:
+//
+//	argument 4 (.b): bad int: strconv.ParseInt: parsing "x": invalid syntax
+//	  [ --a 1 --b x ]
+//	              ^
+//
+// Arguments are quoted as needed for a POSIX shell.
+// If err does not carry position information,
+// FormatError returns err.Error().
+//
+// If err wraps multiple errors, as with the [AllErrors] option,
+// each error is rendered separately, one after the other.
+// Context added by wrapping those errors, e.g. with fmt.Errorf,
+// is rendered on its own line before them.
+func FormatError(args []string, err error) string {
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		errs := joined.Unwrap()
+		msgs := make([]string, 0, len(errs)+1)
+		if jerr, ok := joined.(error); ok && jerr != err {
+			// Keep the context of the wrapping errors, if any.
+			if prefix, ok := strings.CutSuffix(err.Error(), jerr.Error()); ok && len(strings.TrimSpace(prefix)) > 0 {
+				msgs = append(msgs, strings.TrimSpace(prefix))
+			}
+		}
+		for _, err := range errs {
+			msgs = append(msgs, FormatError(args, err))
+		}
+		return strings.Join(msgs, "\n")
+	}
+
+	idx, ok := errorIndex(err)
+	if !ok {
+		return err.Error()
+	}
+
+	var (
+		line   strings.Builder
+		caret  int // column of the caret
+		length int // length of the underline
+	)
+	for i, arg := range args {
+		if i > 0 {
+			line.WriteByte(' ')
+		}
+
+		quoted := shellQuote(arg)
+		if i == idx {
+			caret = utf8.RuneCountInString(line.String())
+			length = utf8.RuneCountInString(quoted)
+		}
+		line.WriteString(quoted)
+	}
+	if idx >= len(args) {
+		// The input ended unexpectedly.
+		// Point just past the end.
+		caret = utf8.RuneCountInString(line.String())
+		if len(args) > 0 {
+			caret++
+		}
+		length = 1
+	}
+
+	var out strings.Builder
+	out.WriteString(err.Error())
+	out.WriteString("\n  ")
+	out.WriteString(line.String())
+	out.WriteString("\n  ")
+	out.WriteString(strings.Repeat(" ", caret))
+	out.WriteString("^")
+	if length > 1 {
+		out.WriteString(strings.Repeat("~", length-1))
+	}
+	return out.String()
+}
+
+// errorIndex reports the index of the argument that caused err,
+// if err carries that information.
+func errorIndex(err error) (int, bool) {
+	var (
+		syntaxErr *SyntaxError
+		decodeErr *DecodeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		return syntaxErr.Index, true
+	case errors.As(err, &decodeErr):
+		return decodeErr.Index, true
+	default:
+		return 0, false
+	}
+}
+
+// shellQuote quotes s for a POSIX shell if necessary.
+func shellQuote(s string) string {
+	if len(s) == 0 {
+		return "''"
+	}
+
+	if strings.IndexFunc(s, needsShellQuote) < 0 {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func needsShellQuote(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return false
+	}
+
+	switch r {
+	case '-', '_', '.', '/', ':', '=', '+', ',', '@', '%', '[', ']':
+		return false
+	}
+	return true
+}
@@ -0,0 +1,149 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFormatError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc   string
+		give   []string
+		object bool
+		want   string
+	}{
+		{
+			desc:   "decode error",
+			give:   []string{"--a", "1", "--b", "x"},
+			object: true,
+			want: `argument 3 (.b): bad int: strconv.ParseInt: parsing "x": invalid syntax
+  --a 1 --b x
+            ^`,
+		},
+		{
+			desc:   "quoted argument",
+			give:   []string{"--a", "1", "--b", "hello world"},
+			object: true,
+			want: `argument 3 (.b): bad int: strconv.ParseInt: parsing "hello world": invalid syntax
+  --a 1 --b 'hello world'
+            ^~~~~~~~~~~~~`,
+		},
+		{
+			desc:   "unknown field",
+			give:   []string{"--a", "1", "--cc", "x"},
+			object: true,
+			want: `argument 2 (.cc): unknown field "cc"
+  --a 1 --cc x
+        ^~~~`,
+		},
+		{
+			desc: "unclosed",
+			give: []string{"[", "--a", "[", "1", "2", "]"},
+			want: `argument 0: unclosed '['
+  [ --a [ 1 2 ]
+  ^`,
+		},
+		{
+			desc: "end of input",
+			give: []string{"[", "--a", "1", "--b", "--"},
+			want: `unexpected end of input: expected a string
+  [ --a 1 --b --
+                 ^`,
+		},
+		{
+			desc: "empty input",
+			want: "unexpected end of input: expected a value\n  \n  ^",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var v struct {
+				A any
+				B int
+			}
+			parse := Parse
+			if tt.object {
+				parse = ParseObject
+			}
+
+			err := parse(tt.give, &v)
+			assert.Equal(t, tt.want, FormatError(tt.give, err))
+		})
+	}
+}
+
+func TestFormatError_allErrors(t *testing.T) {
+	t.Parallel()
+
+	give := []string{"--a", "1", "--b", "x", "--c", "2"}
+
+	var v struct{ A, B int }
+	err := ParseObject(give, &v, AllErrors(true))
+	assert.Equal(t, `argument 3 (.b): bad int: strconv.ParseInt: parsing "x": invalid syntax
+  --a 1 --b x --c 2
+            ^
+argument 4 (.c): unknown field "c"; did you mean "a" or "b"?
+  --a 1 --b x --c 2
+              ^~~`, FormatError(give, err))
+}
+
+func TestFormatError_wrappedAllErrors(t *testing.T) {
+	t.Parallel()
+
+	give := []string{"--a", "x", "--c", "2"}
+
+	var v struct{ A int }
+	err := ParseObject(give, &v, AllErrors(true))
+	err = fmt.Errorf("load config: %w", err)
+	assert.Equal(t, `load config:
+argument 1 (.a): bad int: strconv.ParseInt: parsing "x": invalid syntax
+  --a x --c 2
+      ^
+argument 2 (.c): unknown field "c"; did you mean "a"?
+  --a x --c 2
+        ^~~`, FormatError(give, err))
+}
+
+func TestFormatError_noPosition(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, "great sadness", FormatError([]string{"a"}, errors.New("great sadness")))
+}
+
+func TestShellQuote(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want string
+	}{
+		{"", "''"},
+		{"foo", "foo"},
+		{"--foo=bar", "--foo=bar"},
+		{"[", "["},
+		{"[--]", "[--]"},
+		{"a/b.c:d@e%f,g+h_i", "a/b.c:d@e%f,g+h_i"},
+		{"hello world", "'hello world'"},
+		{"it's", `'it'\''s'`},
+		{"$HOME", "'$HOME'"},
+		{"*", "'*'"},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, shellQuote(tt.give))
+		})
+	}
+}
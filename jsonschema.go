@@ -0,0 +1,346 @@
+package shon
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// JSONSchema generates a JSON Schema (draft 2020-12)
+// describing the values that [Parse] accepts for type t,
+// in their JSON form.
+//
+// The schema follows the rules Parse uses to decode values:
+//
+//   - integers are bounded by the size of their type,
+//     e.g. 0 to 255 for uint8
+//   - Go arrays are limited to their length with maxItems
+//   - maps have additionalProperties for their values
+//   - pointers, slices, and maps accept null, like -n
+//   - struct properties are named like the keys Parse accepts for them,
+//     from the shon:".." tag or the field name in kebab-case,
+//     with other names they accept listed as properties too
+//   - required fields are listed in required,
+//     and unknown keys are not allowed unless a field receives them
+//   - the default:"..", enum:"..", and help:".." struct tags
+//     fill the default, enum, and description keywords
+//
+// Durations, times, and types implementing [encoding.TextUnmarshaler]
+// are described as strings.
+// Positional arguments and subcommands are omitted.
+func JSONSchema(t reflect.Type) ([]byte, error) {
+	dec, err := newDecoder(t)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := newJSONSchema(dec)
+	if err != nil {
+		return nil, err
+	}
+	s.Schema = "https://json-schema.org/draft/2020-12/schema"
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(s); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// jsonSchema is the subset of JSON Schema used by JSONSchema.
+type jsonSchema struct {
+	Schema      string          `json:"$schema,omitempty"`
+	Type        any             `json:"type,omitempty"` // string or []string
+	Description string          `json:"description,omitempty"`
+	Format      string          `json:"format,omitempty"`
+	Enum        []any           `json:"enum,omitempty"`
+	Default     json.RawMessage `json:"default,omitempty"`
+
+	Minimum json.Number `json:"minimum,omitempty"`
+	Maximum json.Number `json:"maximum,omitempty"`
+
+	Items    *jsonSchema `json:"items,omitempty"`
+	MaxItems *int        `json:"maxItems,omitempty"`
+
+	Properties           jsonProperties `json:"properties,omitempty"`
+	Required             []string       `json:"required,omitempty"`
+	AdditionalProperties any            `json:"additionalProperties,omitempty"` // *jsonSchema or bool
+}
+
+// jsonProperties is a JSON object of schemas
+// that retains the order of its properties.
+type jsonProperties []jsonProperty
+
+type jsonProperty struct {
+	name   string
+	schema *jsonSchema
+}
+
+// get returns the schema of the property with the given name,
+// or nil if there isn't one.
+func (ps jsonProperties) get(name string) *jsonSchema {
+	for _, p := range ps {
+		if p.name == name {
+			return p.schema
+		}
+	}
+	return nil
+}
+
+func (ps jsonProperties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, p := range ps {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(p.name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(p.schema)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func newJSONSchema(dec decoder) (*jsonSchema, error) {
+	switch d := dec.(type) {
+	case *boolDecoder:
+		return &jsonSchema{Type: "boolean"}, nil
+
+	case *intDecoder:
+		return &jsonSchema{
+			Type:    "integer",
+			Minimum: json.Number(strconv.FormatInt(math.MinInt64>>(64-d.bits), 10)),
+			Maximum: json.Number(strconv.FormatInt(math.MaxInt64>>(64-d.bits), 10)),
+		}, nil
+
+	case *uintDecoder:
+		return &jsonSchema{
+			Type:    "integer",
+			Minimum: "0",
+			Maximum: json.Number(strconv.FormatUint(math.MaxUint64>>(64-d.bits), 10)),
+		}, nil
+
+	case *floatDecoder:
+		return &jsonSchema{Type: "number"}, nil
+
+	case *complexDecoder, *stringDecoder, *durationDecoder, *textUnmarshalerDecoder:
+		return &jsonSchema{Type: "string"}, nil
+
+	case *timeDecoder:
+		s := jsonSchema{Type: "string"}
+		if d.layout == time.RFC3339 {
+			s.Format = "date-time"
+		}
+		return &s, nil
+
+	case *enumDecoder:
+		s, err := newJSONSchema(d.d)
+		if err != nil {
+			return nil, err
+		}
+		for _, v := range d.values {
+			rv, err := decodeArgs(decodeCtx{Args: []string{v}}, (*parser).value, d.d)
+			if err != nil {
+				return nil, fmt.Errorf("bad enum value %q: %w", v, err)
+			}
+			s.Enum = append(s.Enum, jsonValue(d.d, rv))
+		}
+		return s, nil
+
+	case *ptrDecoder:
+		s, err := newJSONSchema(d.e)
+		if err != nil {
+			return nil, err
+		}
+		s.allowNull()
+		return s, nil
+
+	case *sliceDecoder:
+		items, err := newJSONSchema(d.e)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: []string{"array", "null"}, Items: items}, nil
+
+	case *arrayDecoder:
+		items, err := newJSONSchema(d.e)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: "array", Items: items, MaxItems: &d.len}, nil
+
+	case *mapDecoder:
+		values, err := newJSONSchema(d.v)
+		if err != nil {
+			return nil, err
+		}
+		return &jsonSchema{Type: []string{"object", "null"}, AdditionalProperties: values}, nil
+
+	case *structDecoder:
+		return newStructJSONSchema(d)
+
+	default:
+		// Values of any type, and types implementing Unmarshaler,
+		// may take any form.
+		return &jsonSchema{}, nil
+	}
+}
+
+// allowNull adds null to the values accepted by s.
+func (s *jsonSchema) allowNull() {
+	switch typ := s.Type.(type) {
+	case string:
+		s.Type = []string{typ, "null"}
+	case []string:
+		if !slices.Contains(typ, "null") {
+			s.Type = append(typ, "null")
+		}
+	}
+	if s.Enum != nil && !slices.Contains(s.Enum, nil) {
+		s.Enum = append(s.Enum, nil)
+	}
+}
+
+func newStructJSONSchema(sd *structDecoder) (*jsonSchema, error) {
+	s := jsonSchema{Type: "object"}
+	for _, f := range sd.fields {
+		if f.positional {
+			continue
+		}
+
+		fs, err := newJSONSchema(f.p)
+		if err != nil {
+			return nil, err
+		}
+		fs.Description = f.help
+		if f.dflt != nil {
+			v, err := f.defaultValue(false)
+			if err != nil {
+				return nil, err
+			}
+			fs.Default, err = json.Marshal(jsonValue(f.p, v))
+			if err != nil {
+				return nil, fmt.Errorf("default of field %v: %w", f.names[0], err)
+			}
+		}
+		if f.required {
+			s.Required = append(s.Required, f.names[0])
+		}
+		s.Properties = append(s.Properties, jsonProperty{name: f.names[0], schema: fs})
+	}
+
+	// Other names of fields are accepted too
+	// unless they're taken by another field.
+	for i, f := range sd.fields {
+		for _, name := range f.names[1:] {
+			if j, ok := sd.fieldsByName[name]; ok && j == i && name != f.names[0] {
+				s.Properties = append(s.Properties, jsonProperty{name: name, schema: s.Properties.get(f.names[0])})
+			}
+		}
+	}
+
+	switch {
+	case sd.remaining == nil:
+		s.AdditionalProperties = false
+	case sd.remaining.p != nil:
+		values, err := newJSONSchema(sd.remaining.p.v)
+		if err != nil {
+			return nil, err
+		}
+		s.AdditionalProperties = values
+	}
+	return &s, nil
+}
+
+// jsonValue converts v, which was decoded by dec,
+// to its JSON form as described by newJSONSchema.
+func jsonValue(dec decoder, v reflect.Value) any {
+	switch d := dec.(type) {
+	case *boolDecoder:
+		return v.Bool()
+	case *intDecoder:
+		return v.Int()
+	case *uintDecoder:
+		return v.Uint()
+	case *floatDecoder:
+		return v.Float()
+	case *complexDecoder:
+		return strconv.FormatComplex(v.Complex(), 'g', -1, d.bits)
+	case *stringDecoder:
+		return v.String()
+	case *durationDecoder:
+		return time.Duration(v.Int()).String()
+	case *timeDecoder:
+		return v.Interface().(time.Time).Format(d.layout)
+	case *textUnmarshalerDecoder:
+		if m, ok := v.Interface().(encoding.TextMarshaler); ok {
+			if text, err := m.MarshalText(); err == nil {
+				return string(text)
+			}
+		}
+		return fmt.Sprint(v.Interface())
+	case *enumDecoder:
+		return jsonValue(d.d, v)
+	case *ptrDecoder:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonValue(d.e, v.Elem())
+	case *sliceDecoder:
+		if v.IsNil() {
+			return nil
+		}
+		return jsonItems(d.e, v)
+	case *arrayDecoder:
+		return jsonItems(d.e, v)
+	case *mapDecoder:
+		if v.IsNil() {
+			return nil
+		}
+		m := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			key := fmt.Sprint(jsonValue(d.k, iter.Key()))
+			m[key] = jsonValue(d.v, iter.Value())
+		}
+		return m
+	case *structDecoder:
+		m := make(map[string]any)
+		for _, f := range d.fields {
+			if fv := fieldByIndex(v, f.index, false); fv.IsValid() && !f.positional {
+				m[f.names[0]] = jsonValue(f.p, fv)
+			}
+		}
+		return m
+	default:
+		if !v.IsValid() {
+			return nil
+		}
+		return v.Interface()
+	}
+}
+
+func jsonItems(dec decoder, v reflect.Value) []any {
+	items := make([]any, v.Len())
+	for i := range items {
+		items[i] = jsonValue(dec, v.Index(i))
+	}
+	return items
+}
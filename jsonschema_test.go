@@ -0,0 +1,128 @@
+package shon
+
+import (
+	"encoding/json"
+	"net/netip"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `shon:"host,required" help:"Host name or IP address."`
+		Port uint16 `shon:"port" default:"80"`
+	}
+
+	type options struct {
+		Verbose bool              `shon:"verbose" help:"Print more output."`
+		Name    string            `shon:"name" default:"'John Doe'"`
+		Level   string            `shon:"level" enum:"debug info"`
+		Codes   []int             `shon:"codes" enum:"200 404"`
+		Retries *int8             `shon:"retries"`
+		Ratio   float32           `shon:"ratio"`
+		Timeout time.Duration     `shon:"timeout" default:"5s"`
+		Since   time.Time         `shon:"since"`
+		Day     time.Time         `shon:"day,layout=2006-01-02"`
+		Addr    netip.Addr        `shon:"addr" default:"127.0.0.1"`
+		Pair    [2]string         `shon:"pair"`
+		Servers []server          `shon:"servers" default:"[ [ --host a ] ]"`
+		Labels  map[string]string `shon:"labels"`
+		Extra   any               `shon:"extra"`
+		MaxJobs int
+		Dest    string `shon:"dest,arg"`
+	}
+
+	got, err := JSONSchema(reflect.TypeOf(options{}))
+	require.NoError(t, err)
+	assertGolden(t, filepath.Join("testdata", "jsonschema", "options.json"), string(got))
+}
+
+func TestJSONSchema_scalars(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give any
+		want string
+	}{
+		{"int8", int8(0), `{"type": "integer", "minimum": -128, "maximum": 127}`},
+		{"int64", int64(0), `{"type": "integer", "minimum": -9223372036854775808, "maximum": 9223372036854775807}`},
+		{"uint16", uint16(0), `{"type": "integer", "minimum": 0, "maximum": 65535}`},
+		{"uint64", uint64(0), `{"type": "integer", "minimum": 0, "maximum": 18446744073709551615}`},
+		{"float64", float64(0), `{"type": "number"}`},
+		{"complex64", complex64(0), `{"type": "string"}`},
+		{"pointer", new(bool), `{"type": ["boolean", "null"]}`},
+		{"array", [3]bool{}, `{"type": "array", "items": {"type": "boolean"}, "maxItems": 3}`},
+		{"map", map[int]string{}, `{"type": ["object", "null"], "additionalProperties": {"type": "string"}}`},
+		{"slice pointer", new([]bool), `{"type": ["array", "null"], "items": {"type": "boolean"}}`},
+		{"any", []any{}, `{"type": ["array", "null"], "items": {}}`},
+		{
+			"remaining",
+			struct {
+				Rest map[string]int `shon:",remaining"`
+			}{},
+			`{"type": "object", "additionalProperties": {"type": "integer", "minimum": -9223372036854775808, "maximum": 9223372036854775807}}`,
+		},
+		{
+			"passthrough",
+			struct {
+				Rest []string `shon:",passthrough"`
+			}{},
+			`{"type": "object"}`,
+		},
+		{
+			"pointer enum",
+			struct {
+				Mode *string `shon:"mode" enum:"a b"`
+			}{},
+			`{"type": "object", "properties": {"mode": {"type": ["string", "null"], "enum": ["a", "b", null]}}, "additionalProperties": false}`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := JSONSchema(reflect.TypeOf(tt.give))
+			require.NoError(t, err)
+
+			var schema map[string]any
+			require.NoError(t, json.Unmarshal(got, &schema))
+			assert.Equal(t, "https://json-schema.org/draft/2020-12/schema", schema["$schema"])
+			delete(schema, "$schema")
+
+			body, err := json.Marshal(schema)
+			require.NoError(t, err)
+			assert.JSONEq(t, tt.want, string(body))
+		})
+	}
+}
+
+func TestJSONSchema_errors(t *testing.T) {
+	t.Parallel()
+
+	t.Run("unsupported", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := JSONSchema(reflect.TypeOf(make(chan int)))
+		assert.ErrorContains(t, err, "unsupported type chan int")
+	})
+
+	t.Run("bad enum", func(t *testing.T) {
+		t.Parallel()
+
+		type options struct {
+			Port int `shon:"port" enum:"80 http"`
+		}
+		_, err := JSONSchema(reflect.TypeOf(options{}))
+		assert.ErrorContains(t, err, `bad enum value "http"`)
+	})
+}
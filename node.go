@@ -0,0 +1,240 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Node is a single value in a parsed SHON document.
+//
+// Use [ParseTree] to build a tree of Nodes from a list of arguments
+// without decoding it into a Go value,
+// and [Node.Args] to turn a tree back into a list of arguments.
+type Node struct {
+	// Kind is the kind of value held by this node.
+	Kind Kind
+
+	// Text is the text of a StringKind or ScalarKind node.
+	Text string
+
+	// Bool is the value of a BoolKind node.
+	Bool bool
+
+	// Escaped reports whether a StringKind node was preceded by '--'.
+	Escaped bool
+
+	// Number reports whether a ScalarKind node looks like a number.
+	Number bool
+
+	// Children holds the items of an ArrayKind node,
+	// or the values of an ObjectKind node, in order.
+	Children []*Node
+
+	// Keys holds the keys of an ObjectKind node, in order.
+	// Keys[i] is the key for Children[i].
+	Keys []string
+
+	// Start and End are the indexes of the arguments
+	// that this node was parsed from: args[Start:End].
+	//
+	// For values specified inline with their key, like '--key=value',
+	// the range includes the argument holding the key.
+	Start, End int
+
+	// KeyIndex is the index of the argument holding this node's key
+	// if it's a value inside an object, and -1 otherwise.
+	KeyIndex int
+}
+
+// ParseTree parses args into a tree of [Node]s.
+//
+// It accepts the same input as [Parse]
+// for a value of type any.
+func ParseTree(args []string) (*Node, error) {
+	return parseTree(args, (*parser).value)
+}
+
+// ParseObjectTree is a variant of [ParseTree]
+// that assumes an object at the top level
+// similarly to [ParseObject].
+func ParseObjectTree(args []string) (*Node, error) {
+	return parseTree(args, (*parser).object)
+}
+
+func parseTree(args []string, readFn func(*parser) (value, error)) (*Node, error) {
+	cur := sliceCursor{args: args}
+	p := parser{cursor: &cur}
+
+	val, err := readFn(&p)
+	if err != nil {
+		return nil, err
+	}
+
+	n, err := p.node(val)
+	if err != nil {
+		return nil, err
+	}
+
+	if cur.more() {
+		return nil, syntaxErrorf(cur.pos, cur.args[cur.pos], "unexpected arguments: %q", cur.args[cur.pos:])
+	}
+	return n, nil
+}
+
+// node reads v fully and builds a Node from it.
+func (p *parser) node(v value) (*Node, error) {
+	n := Node{
+		Kind:     Kind(v.t),
+		Start:    v.pos,
+		KeyIndex: -1,
+	}
+
+	switch v.t {
+	case boolType:
+		n.Bool = v.b
+
+	case stringType:
+		n.Text = v.s
+		n.Escaped = v.esc
+
+	case scalarType:
+		n.Text = v.s
+		n.Number = v.num
+
+	case arrayType:
+		for r := v.i.(reader); r.more(); {
+			item, err := r.next()
+			if err != nil {
+				return nil, err
+			}
+
+			child, err := p.node(item)
+			if err != nil {
+				return nil, err
+			}
+
+			n.Children = append(n.Children, child)
+		}
+
+	case objectType:
+		for r := v.i.(objectReader); r.more(); {
+			key, item, err := r.next()
+			if err != nil {
+				return nil, err
+			}
+
+			child, err := p.node(item)
+			if err != nil {
+				return nil, err
+			}
+			child.KeyIndex = item.kpos
+
+			n.Keys = append(n.Keys, key)
+			n.Children = append(n.Children, child)
+		}
+	}
+
+	n.End = p.index()
+	return &n, nil
+}
+
+// Args renders the tree rooted at n into a list of SHON arguments.
+//
+// The arguments are in a canonical form
+// and may not match the ones the tree was parsed from exactly.
+// For example, '[ ]' is rendered as '[]',
+// and '--key=value' is rendered as '--key value'.
+// Positions recorded on the nodes are ignored.
+//
+// Args returns an error if the tree cannot be represented in SHON,
+// e.g. if a [ScalarKind] node holds text that would be read
+// as something other than a scalar, like "-x" or "[".
+func (n *Node) Args() ([]string, error) {
+	return n.appendArgs(nil)
+}
+
+func (n *Node) appendArgs(args []string) ([]string, error) {
+	switch n.Kind {
+	case NullKind:
+		return append(args, "-n"), nil
+
+	case BoolKind:
+		if n.Bool {
+			return append(args, "-t"), nil
+		}
+		return append(args, "-f"), nil
+
+	case StringKind:
+		if n.Escaped {
+			return append(args, "--", n.Text), nil
+		}
+		return appendString(args, n.Text), nil
+
+	case ScalarKind:
+		if !isScalar(n.Text) {
+			return args, fmt.Errorf("%q is not a valid scalar: use StringKind", n.Text)
+		}
+		return append(args, n.Text), nil
+
+	case ArrayKind:
+		if len(n.Children) == 0 {
+			return append(args, "[]"), nil
+		}
+
+		args = append(args, "[")
+		for _, child := range n.Children {
+			var err error
+			args, err = child.appendArgs(args)
+			if err != nil {
+				return args, err
+			}
+		}
+		return append(args, "]"), nil
+
+	case ObjectKind:
+		if len(n.Keys) != len(n.Children) {
+			return args, fmt.Errorf("object has %d keys and %d values", len(n.Keys), len(n.Children))
+		}
+		if len(n.Children) == 0 {
+			return append(args, "[--]"), nil
+		}
+
+		args = append(args, "[")
+		for i, child := range n.Children {
+			key := n.Keys[i]
+			if strings.Contains(key, "=") {
+				return args, fmt.Errorf("key %q must not contain '='", key)
+			}
+
+			valueArgs, err := child.appendArgs(nil)
+			if err != nil {
+				return args, err
+			}
+
+			if len(key) == 0 {
+				// '--' on its own is not a key.
+				// Use the inline form '--=value' instead.
+				args = append(args, "--="+valueArgs[0])
+				args = append(args, valueArgs[1:]...)
+			} else {
+				args = append(args, "--"+key)
+				args = append(args, valueArgs...)
+			}
+		}
+		return append(args, "]"), nil
+
+	default:
+		return args, errors.New("unexpected " + n.Kind.String())
+	}
+}
+
+// isScalar reports whether s reads back as a scalar
+// when it's used as an argument on its own.
+func isScalar(s string) bool {
+	switch s {
+	case "", "[", "]", "[]", "[--]", "-t", "-f", "-n", "--":
+		return false
+	}
+	return s[0] != '-' || isNumeric(s)
+}
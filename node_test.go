@@ -0,0 +1,315 @@
+package shon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseTree(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give []string
+		want *Node
+	}{
+		{
+			desc: "scalar",
+			give: []string{"foo"},
+			want: &Node{Kind: ScalarKind, Text: "foo", Start: 0, End: 1, KeyIndex: -1},
+		},
+		{
+			desc: "number",
+			give: []string{"-42"},
+			want: &Node{Kind: ScalarKind, Text: "-42", Number: true, Start: 0, End: 1, KeyIndex: -1},
+		},
+		{
+			desc: "escaped string",
+			give: []string{"--", "42"},
+			want: &Node{Kind: StringKind, Text: "42", Escaped: true, Start: 0, End: 2, KeyIndex: -1},
+		},
+		{
+			desc: "empty string",
+			give: []string{""},
+			want: &Node{Kind: StringKind, Start: 0, End: 1, KeyIndex: -1},
+		},
+		{
+			desc: "empty array/spaced",
+			give: []string{"[", "]"},
+			want: &Node{Kind: ArrayKind, Start: 0, End: 2, KeyIndex: -1},
+		},
+		{
+			desc: "array",
+			give: []string{"[", "-t", "-n", "[--]", "]"},
+			want: &Node{
+				Kind: ArrayKind,
+				Children: []*Node{
+					{Kind: BoolKind, Bool: true, Start: 1, End: 2, KeyIndex: -1},
+					{Kind: NullKind, Start: 2, End: 3, KeyIndex: -1},
+					{Kind: ObjectKind, Start: 3, End: 4, KeyIndex: -1},
+				},
+				Start:    0,
+				End:      5,
+				KeyIndex: -1,
+			},
+		},
+		{
+			desc: "object",
+			give: []string{
+				"[",
+				"--a", "[", "1", "]",
+				"--b=--", "x",
+				"--c", "--", "y",
+				"]",
+			},
+			want: &Node{
+				Kind: ObjectKind,
+				Keys: []string{"a", "b", "c"},
+				Children: []*Node{
+					{
+						Kind: ArrayKind,
+						Children: []*Node{
+							{Kind: ScalarKind, Text: "1", Number: true, Start: 3, End: 4, KeyIndex: -1},
+						},
+						Start:    2,
+						End:      5,
+						KeyIndex: 1,
+					},
+					{Kind: StringKind, Text: "x", Escaped: true, Start: 5, End: 7, KeyIndex: 5},
+					{Kind: StringKind, Text: "y", Escaped: true, Start: 8, End: 10, KeyIndex: 7},
+				},
+				Start:    0,
+				End:      11,
+				KeyIndex: -1,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := ParseTree(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestParseObjectTree(t *testing.T) {
+	t.Parallel()
+
+	got, err := ParseObjectTree([]string{"--name", "foo", "--on=-t"})
+	require.NoError(t, err)
+	assert.Equal(t, &Node{
+		Kind: ObjectKind,
+		Keys: []string{"name", "on"},
+		Children: []*Node{
+			{Kind: ScalarKind, Text: "foo", Start: 1, End: 2, KeyIndex: 0},
+			{Kind: BoolKind, Bool: true, Start: 2, End: 3, KeyIndex: 2},
+		},
+		Start:    0,
+		End:      3,
+		KeyIndex: -1,
+	}, got)
+}
+
+func TestParseTree_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    []string
+		wantErr string
+	}{
+		{desc: "empty", wantErr: "expected a value"},
+		{desc: "trailing", give: []string{"a", "b"}, wantErr: `unexpected arguments: ["b"]`},
+		{desc: "nested", give: []string{"[", "[", "-x", "]", "]"}, wantErr: `unexpected flag "-x"`},
+		{desc: "object", give: []string{"[", "--a", "1", "b", "]"}, wantErr: `expected object key, got "b"`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ParseTree(tt.give)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestNode_Args(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give []string
+		want []string // defaults to give
+	}{
+		{desc: "scalar", give: []string{"foo"}},
+		{desc: "escaped", give: []string{"--", "foo"}},
+		{desc: "empty string", give: []string{""}},
+		{desc: "bools", give: []string{"[", "-t", "-f", "-n", "]"}},
+		{desc: "empty array", give: []string{"[", "]"}, want: []string{"[]"}},
+		{desc: "empty object", give: []string{"[--]"}},
+		{
+			desc: "inline values",
+			give: []string{"[", "--a=1", "--b=--", "-x", "--c=[", "x", "]", "]"},
+			want: []string{"[", "--a", "1", "--b", "--", "-x", "--c", "[", "x", "]", "]"},
+		},
+		{
+			desc: "empty key",
+			give: []string{"[", "--=[", "1", "]", "--x", "y", "]"},
+		},
+		{
+			desc: "nested",
+			give: []string{"[", "[", "--k", "[", "1", "2", "]", "]", "[]", "]"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			want := tt.want
+			if want == nil {
+				want = tt.give
+			}
+
+			n, err := ParseTree(tt.give)
+			require.NoError(t, err)
+
+			got, err := n.Args()
+			require.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestNode_Args_constructed(t *testing.T) {
+	t.Parallel()
+
+	n := &Node{
+		Kind: ObjectKind,
+		Keys: []string{"name", "items"},
+		Children: []*Node{
+			{Kind: StringKind, Text: "-x"},
+			{Kind: ArrayKind, Children: []*Node{
+				{Kind: ScalarKind, Text: "1"},
+				{Kind: StringKind, Text: "1"},
+			}},
+		},
+	}
+
+	got, err := n.Args()
+	require.NoError(t, err)
+	assert.Equal(t, []string{
+		"[",
+		"--name", "--", "-x",
+		"--items", "[", "1", "--", "1", "]",
+		"]",
+	}, got)
+}
+
+func TestNode_Args_scalars(t *testing.T) {
+	t.Parallel()
+
+	for _, text := range []string{"foo", "-5", "+1.5e3", "1-2", "a=b", "x]"} {
+		text := text
+		t.Run(text, func(t *testing.T) {
+			t.Parallel()
+
+			args, err := (&Node{Kind: ScalarKind, Text: text}).Args()
+			require.NoError(t, err)
+
+			got, err := ParseTree(args)
+			require.NoError(t, err)
+			assert.Equal(t, ScalarKind, got.Kind)
+			assert.Equal(t, text, got.Text)
+		})
+	}
+}
+
+func TestNode_Args_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    *Node
+		wantErr string
+	}{
+		{
+			desc:    "invalid kind",
+			give:    &Node{},
+			wantErr: "unexpected Kind(0)",
+		},
+		{
+			desc:    "nested invalid kind",
+			give:    &Node{Kind: ArrayKind, Children: []*Node{{Kind: 42}}},
+			wantErr: "unexpected Kind(42)",
+		},
+		{
+			desc:    "mismatched keys",
+			give:    &Node{Kind: ObjectKind, Keys: []string{"a"}},
+			wantErr: "object has 1 keys and 0 values",
+		},
+		{
+			desc: "key with equals",
+			give: &Node{
+				Kind:     ObjectKind,
+				Keys:     []string{"a=b"},
+				Children: []*Node{{Kind: NullKind}},
+			},
+			wantErr: `key "a=b" must not contain '='`,
+		},
+		{
+			desc:    "scalar/flag",
+			give:    &Node{Kind: ScalarKind, Text: "-x"},
+			wantErr: `"-x" is not a valid scalar: use StringKind`,
+		},
+		{
+			desc:    "scalar/key",
+			give:    &Node{Kind: ArrayKind, Children: []*Node{{Kind: ScalarKind, Text: "--foo"}}},
+			wantErr: `"--foo" is not a valid scalar: use StringKind`,
+		},
+		{
+			desc:    "scalar/bracket",
+			give:    &Node{Kind: ScalarKind, Text: "["},
+			wantErr: `"[" is not a valid scalar: use StringKind`,
+		},
+		{
+			desc:    "scalar/bool",
+			give:    &Node{Kind: ScalarKind, Text: "-t"},
+			wantErr: `"-t" is not a valid scalar: use StringKind`,
+		},
+		{
+			desc:    "scalar/empty",
+			give:    &Node{Kind: ScalarKind},
+			wantErr: `"" is not a valid scalar: use StringKind`,
+		},
+		{
+			desc: "bad value",
+			give: &Node{
+				Kind:     ObjectKind,
+				Keys:     []string{"a"},
+				Children: []*Node{{}},
+			},
+			wantErr: "unexpected Kind(0)",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := tt.give.Args()
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
@@ -8,6 +8,11 @@ type ParseOption interface{ applyParseOption(*parseOptions) }
 type parseOptions struct {
 	useNumber      bool
 	implicitObject bool
+	prefix         bool
+	allErrors      bool
+	merge          bool
+	appendSlices   bool
+	duplicateKeys  DuplicateKeyPolicy
 }
 
 func buildParseOptions(opts ...ParseOption) parseOptions {
@@ -18,6 +23,19 @@ func buildParseOptions(opts ...ParseOption) parseOptions {
 	return po
 }
 
+// decodeCtx builds a decodeCtx for args with these options.
+// The caller is responsible for setting up AllErrors.
+func (po *parseOptions) decodeCtx(args []string) decodeCtx {
+	return decodeCtx{
+		UseNumber:    po.useNumber,
+		Args:         args,
+		Merge:        po.merge,
+		AppendSlices: po.appendSlices,
+		DupKeys:      po.duplicateKeys,
+		Writes:       new(pointerWrites),
+	}
+}
+
 // UseNumber specifies whether the decoder should read numeric values
 // as [Number] objects for fields of the type 'any'.
 //
@@ -39,6 +57,164 @@ func (o useNumberOption) applyParseOption(opts *parseOptions) {
 	opts.useNumber = bool(o)
 }
 
+// AllErrors specifies whether the decoder should keep going
+// after it encounters a value that it cannot decode,
+// and report all such errors together.
+//
+// With this option, unknown fields, mismatched types,
+// and malformed values are all reported
+// in a single error that wraps a [*DecodeError] for each problem.
+// The error is compatible with [errors.Join].
+// If there's only one problem, its error is returned as-is.
+// Syntax errors still halt decoding immediately.
+//
+// Defaults to false.
+func AllErrors(b bool) ParseOption {
+	return allErrorsOption(b)
+}
+
+type allErrorsOption bool
+
+func (o allErrorsOption) String() string {
+	return fmt.Sprintf("AllErrors(%v)", bool(o))
+}
+
+func (o allErrorsOption) applyParseOption(opts *parseOptions) {
+	opts.allErrors = bool(o)
+}
+
+// Merge specifies whether Parse should decode into
+// the existing value pointed to by its argument
+// instead of replacing it.
+// This allows loading values from another source first,
+// and then overriding them with arguments.
+//
+// With this option:
+//
+//   - struct fields that are not specified are left as-is,
+//     and default values are not applied to them
+//   - maps are merged key by key
+//   - non-nil pointers are decoded into in-place,
+//     including those of subcommands for [ParseCommand]
+//   - any fields holding non-nil pointers are decoded into in-place,
+//     and those holding structs, maps, or slices are merged
+//     into a value of the same type, if the input has the same shape
+//   - slices are replaced, unless [AppendSlices] is also used
+//
+// Struct fields and map values are merged recursively.
+// Other values are replaced.
+// Existing values satisfy required fields.
+//
+// If Parse fails, the value and everything it points to are left as-is.
+//
+// Defaults to false.
+func Merge(b bool) ParseOption {
+	return mergeOption(b)
+}
+
+type mergeOption bool
+
+func (o mergeOption) String() string {
+	return fmt.Sprintf("Merge(%v)", bool(o))
+}
+
+func (o mergeOption) applyParseOption(opts *parseOptions) {
+	opts.merge = bool(o)
+}
+
+// AppendSlices specifies whether slices should be appended to
+// instead of replaced when used with [Merge].
+// It has no effect without Merge.
+//
+// Defaults to false.
+func AppendSlices(b bool) ParseOption {
+	return appendSlicesOption(b)
+}
+
+type appendSlicesOption bool
+
+func (o appendSlicesOption) String() string {
+	return fmt.Sprintf("AppendSlices(%v)", bool(o))
+}
+
+func (o appendSlicesOption) applyParseOption(opts *parseOptions) {
+	opts.appendSlices = bool(o)
+}
+
+// DuplicateKeyPolicy specifies how to handle keys
+// that are repeated in an object.
+// See [DuplicateKeys].
+type DuplicateKeyPolicy int
+
+const (
+	// DuplicateLastWins uses the value of the last occurrence of a key.
+	// This is the default.
+	DuplicateLastWins DuplicateKeyPolicy = iota
+
+	// DuplicateFirstWins uses the value of the first occurrence of a key,
+	// and ignores the rest.
+	DuplicateFirstWins
+
+	// DuplicateError reports a [*DecodeError] for repeated keys.
+	DuplicateError
+
+	// DuplicateAccumulate collects the values of all occurrences
+	// of a key into a slice.
+	//
+	// For slice fields and map values,
+	// each occurrence of the key adds one item to the slice.
+	// If the value is an array,
+	// its items are added instead
+	// unless the slice holds arrays or slices itself.
+	// So these are all equivalent for a []string field:
+	//
+	//	--input a --input b
+	//	--input [ a b ]
+	//	--input a --input [ b ]
+	//
+	// A null value (-n) adds no items
+	// unless the slice holds pointers, slices, maps, or any values.
+	//
+	// For any fields and map values of type any,
+	// including fields with shon:",remaining",
+	// repeated keys are turned into a []any holding all their values.
+	// Other fields use the last value.
+	DuplicateAccumulate
+)
+
+func (p DuplicateKeyPolicy) String() string {
+	switch p {
+	case DuplicateLastWins:
+		return "DuplicateLastWins"
+	case DuplicateFirstWins:
+		return "DuplicateFirstWins"
+	case DuplicateError:
+		return "DuplicateError"
+	case DuplicateAccumulate:
+		return "DuplicateAccumulate"
+	default:
+		return fmt.Sprintf("DuplicateKeyPolicy(%d)", int(p))
+	}
+}
+
+// DuplicateKeys specifies how to handle keys
+// that are repeated in an object.
+//
+// Defaults to [DuplicateLastWins].
+func DuplicateKeys(p DuplicateKeyPolicy) ParseOption {
+	return duplicateKeysOption(p)
+}
+
+type duplicateKeysOption DuplicateKeyPolicy
+
+func (o duplicateKeysOption) String() string {
+	return fmt.Sprintf("DuplicateKeys(%v)", DuplicateKeyPolicy(o))
+}
+
+func (o duplicateKeysOption) applyParseOption(opts *parseOptions) {
+	opts.duplicateKeys = DuplicateKeyPolicy(o)
+}
+
 // implicitObject specifies that Parse should assume it's inside an object
 // at the top level.
 // With this,
@@ -63,3 +239,22 @@ func (o implicitObjectOption) String() string {
 func (o implicitObjectOption) applyParseOption(opts *parseOptions) {
 	opts.implicitObject = bool(o)
 }
+
+// prefix specifies that Parse should stop after a single value
+// instead of failing if there are more arguments.
+// For implicit objects, the object ends at the first positional argument.
+//
+// This option is not public -- users should use the ParsePrefix function.
+func prefix(b bool) ParseOption {
+	return prefixOption(b)
+}
+
+type prefixOption bool
+
+func (o prefixOption) String() string {
+	return fmt.Sprintf("prefix(%v)", bool(o))
+}
+
+func (o prefixOption) applyParseOption(opts *parseOptions) {
+	opts.prefix = bool(o)
+}
@@ -34,6 +34,29 @@ func TestBuildParseOptions(t *testing.T) {
 			},
 			want: parseOptions{useNumber: false},
 		},
+		{
+			desc: "all errors",
+			give: []ParseOption{
+				AllErrors(true),
+			},
+			want: parseOptions{allErrors: true},
+		},
+		{
+			desc: "merge",
+			give: []ParseOption{
+				Merge(true),
+				AppendSlices(true),
+			},
+			want: parseOptions{merge: true, appendSlices: true},
+		},
+		{
+			desc: "duplicate keys",
+			give: []ParseOption{
+				DuplicateKeys(DuplicateError),
+				DuplicateKeys(DuplicateAccumulate),
+			},
+			want: parseOptions{duplicateKeys: DuplicateAccumulate},
+		},
 	}
 
 	for _, tt := range tests {
@@ -56,6 +79,12 @@ func TestParseOption_String(t *testing.T) {
 	}{
 		{UseNumber(false), "UseNumber(false)"},
 		{UseNumber(true), "UseNumber(true)"},
+		{AllErrors(false), "AllErrors(false)"},
+		{AllErrors(true), "AllErrors(true)"},
+		{Merge(true), "Merge(true)"},
+		{AppendSlices(false), "AppendSlices(false)"},
+		{DuplicateKeys(DuplicateFirstWins), "DuplicateKeys(DuplicateFirstWins)"},
+		{DuplicateKeys(42), "DuplicateKeys(DuplicateKeyPolicy(42))"},
 	}
 
 	for i, tt := range tests {
@@ -19,16 +19,26 @@ import (
 //   - any int, uint, float, or complex type:
 //     a numeric value parsed to that type
 //   - string: a value picked verbatim, or preceded by a '--' argument
-//   - slice: a collection of values surrounded by '[', ']'
+//   - slice: a collection of values surrounded by '[', ']',
+//     or -n for a nil slice
 //   - array: up to as many values as the array has room for,
 //     surrounded by '[', ']'
 //   - map: key-value pairs where the key is prefixed with '--',
-//     surrounded by '[', ']'
+//     surrounded by '[', ']', or -n for a nil map
 //   - struct: key-value where the key is an exported field name
 //     in kebab-case and prefixed with '--',
 //     and the whole object is surrounded by '[', ']'
-//   - pointer types: parsed as the target type
+//   - pointer types: parsed as the target type, or -n for a nil pointer
 //   - any or interface{}: accepts anything, see below for more
+//   - time.Duration: a duration in a form accepted by [time.ParseDuration]
+//     like "5s" or "1h30m"
+//   - time.Time: a time in RFC 3339 format, or in the layout specified
+//     with the field's struct tag, e.g. shon:"when,layout=2006-01-02"
+//   - types implementing [Unmarshaler]: anything accepted by the type
+//   - types implementing [encoding.TextUnmarshaler]:
+//     a value picked verbatim, or preceded by a '--' argument,
+//     and parsed by the type's UnmarshalText method.
+//     This includes map keys.
 //
 // # Parsing structs
 //
@@ -53,6 +63,99 @@ import (
 //		LastName  string `shon:"last-name"`
 //	}
 //
+// The tag may be followed by a comma-separated list of options.
+// The following options are supported:
+//
+//   - required: the field must be specified in the object.
+//     Parse reports a [*MissingFieldsError] listing all missing
+//     required fields of an object otherwise.
+//   - inline: promote the fields of this struct field to the parent.
+//     See Embedded structs below.
+//   - remaining: the field receives all keys of the object
+//     that don't match any other field instead of failing.
+//     The field must be a map with string keys, e.g. map[string]any.
+//     Only one field in a struct may use this option.
+//   - passthrough: like remaining, but the field receives the unknown keys
+//     and their values as the original arguments, in order and undecoded.
+//     The field must be a []string.
+//     This is useful to forward options to another program.
+//   - arg, args: the field is a positional argument.
+//     See [ParseObject].
+//   - layout=LAYOUT: layout for time.Time fields and their elements.
+//     This must be the last option in the tag as layouts may contain commas.
+//
+// The name may be omitted to specify options without changing the name.
+//
+//	type Event struct {
+//		Date time.Time `shon:",layout=2006-01-02"`
+//	}
+//
+// # Embedded structs
+//
+// The fields of embedded structs and pointers to structs
+// are promoted to the parent struct
+// as if they were declared there.
+//
+//	type CommonOptions struct {
+//		Verbose bool `shon:"verbose"`
+//	}
+//
+//	type BuildOptions struct {
+//		CommonOptions
+//		Target string `shon:"target"`
+//	}
+//
+// The above accepts:
+//
+//	[ --verbose -t --target foo ]
+//
+// Conflicting field names are resolved using the same rules
+// as encoding/json.
+// Embedded pointers are allocated as needed.
+// To keep an embedded struct nested under its own key,
+// give it a name in its tag:
+//
+//	type BuildOptions struct {
+//		CommonOptions `shon:"common"`
+//	}
+//
+// Use the inline tag option to promote the fields
+// of a struct field that isn't embedded:
+//
+//	type BuildOptions struct {
+//		Common CommonOptions `shon:",inline"`
+//	}
+//
+// # Default values
+//
+// Fields that are not specified are left as their zero values.
+// Use the default:".." tag to specify a different default value.
+// The contents of the tag are parsed as SHON,
+// with arguments separated by spaces and quoted as needed
+// like in a POSIX shell.
+//
+//	type Config struct {
+//		Timeout time.Duration `shon:"timeout" default:"5s"`
+//		Tags    []string      `shon:"tags" default:"[ a b ]"`
+//		Name    string        `shon:"name" default:"'John Doe'"`
+//	}
+//
+// Defaults also apply to the fields of nested structs
+// that are not specified at all.
+// A field cannot be both required and have a default.
+//
+// # Enumerations
+//
+// Use the enum:".." tag to restrict a field to a fixed list of values.
+// The tag lists the values like the default:".." tag does.
+// For slices, arrays, maps, and pointers, the list applies to the elements.
+//
+//	type Options struct {
+//		Level string `shon:"level" enum:"debug info warn error"`
+//	}
+//
+// Parse reports a [*DecodeError] for any other value.
+//
 // # Parsing any value
 //
 // As a special case, a field of type any (interface{})
@@ -67,43 +170,125 @@ import (
 //
 // If the [UseNumber] option is used, int64 and float64 above
 // will be replaced with [Number].
+//
+// # Errors
+//
+// Parse reports a [*SyntaxError] if args is not valid SHON,
+// and a [*DecodeError] if it cannot be decoded into v.
+// Both errors record the position of the offending argument.
 func Parse(args []string, v any, opts ...ParseOption) error {
+	_, err := parse(args, v, opts...)
+	return err
+}
+
+// ParsePrefix is a variant of [Parse] that decodes a single value
+// from the start of args and returns the arguments that follow it
+// instead of failing if there are any.
+//
+// This allows handing the rest of the arguments to another parser.
+func ParsePrefix(args []string, v any, opts ...ParseOption) (rest []string, err error) {
+	return parse(args, v, append(opts, prefix(true))...)
+}
+
+// ParseObjectPrefix is a variant of [ParseObject]
+// that decodes the top-level object from the start of args
+// and returns the arguments that follow it.
+//
+// The object ends at the first argument that isn't a key,
+// including '--'.
+// For example, given:
+//
+//	--verbose -t build --target foo
+//
+// ParseObjectPrefix decodes '--verbose -t' into v
+// and returns 'build --target foo'.
+// Positional argument fields of v are not filled.
+func ParseObjectPrefix(args []string, v any, opts ...ParseOption) (rest []string, err error) {
+	return parse(args, v, append(opts, implicitObject(true), prefix(true))...)
+}
+
+func parse(args []string, v any, opts ...ParseOption) (rest []string, err error) {
 	dst := reflect.ValueOf(v)
 	if dst.Kind() != reflect.Pointer {
-		return errors.New("must be a pointer")
+		return nil, errors.New("must be a pointer")
 	}
 
 	options := buildParseOptions(opts...)
 
-	cur := sliceCursor{args: args}
-	p := parser{cursor: &cur}
-
 	readFn := (*parser).value
-	if options.implicitObject {
+	switch {
+	case options.implicitObject && options.prefix:
+		readFn = (*parser).objectPrefix
+	case options.implicitObject:
 		readFn = (*parser).object
 	}
 
-	val, err := readFn(&p)
+	dec, err := newDecoder(dst.Type().Elem())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	dec, err := newDecoder(dst.Type().Elem())
+	ctx := options.decodeCtx(args).into(dst.Elem())
+
+	var errs []error
+	if options.allErrors {
+		ctx.Errs = &errs
+	}
+
+	var (
+		res reflect.Value
+		end int
+	)
+	if options.prefix {
+		res, end, err = decodePrefix(ctx, 0, readFn, dec)
+	} else {
+		res, err = decodeArgs(ctx, readFn, dec)
+	}
+	if errors.Is(err, ErrHelp) {
+		return nil, ErrHelp
+	}
 	if err != nil {
-		return err
+		errs = append(errs, err)
+	}
+	if len(errs) > 0 {
+		return nil, joinErrors(errs)
 	}
 
-	res, err := dec.Decode(decodeCtx{UseNumber: options.useNumber}, val)
+	ctx.Writes.commit()
+	dst.Elem().Set(res)
+	return args[end:], nil
+}
+
+// decodeArgs reads a value from ctx.Args with readFn
+// and decodes it with dec.
+// All arguments must be consumed.
+func decodeArgs(ctx decodeCtx, readFn func(*parser) (value, error), dec decoder) (reflect.Value, error) {
+	res, end, err := decodePrefix(ctx, 0, readFn, dec)
 	if err != nil {
-		return err
+		return res, err
 	}
 
-	if cur.more() {
-		return fmt.Errorf("unexpected arguments: %q", cur.args[cur.pos:])
+	if args := ctx.Args; end < len(args) {
+		return res, syntaxErrorf(end, args[end], "unexpected arguments: %q", args[end:])
 	}
+	return res, nil
+}
 
-	dst.Elem().Set(res)
-	return nil
+// decodePrefix reads a value from ctx.Args starting at index start
+// with readFn and decodes it with dec.
+// It reports the index of the first argument that was not consumed.
+func decodePrefix(ctx decodeCtx, start int, readFn func(*parser) (value, error), dec decoder) (reflect.Value, int, error) {
+	cur := sliceCursor{args: ctx.Args, pos: start}
+	p := parser{cursor: &cur}
+	ctx.Cursor = &cur
+
+	val, err := readFn(&p)
+	if err != nil {
+		return reflect.Value{}, cur.pos, err
+	}
+
+	res, err := dec.Decode(ctx, val)
+	return res, cur.pos, err
 }
 
 // ParseObject is a variant of [Parse] that assumes an object at the top level.
@@ -117,6 +302,41 @@ func Parse(args []string, v any, opts ...ParseOption) error {
 //	[ --first-name Jack --last-name Sparrow ]
 //
 // This makes it a good starting point for CLIs that want to accept flags.
+// Use [DuplicateKeys] with [DuplicateAccumulate]
+// to allow repeating flags to fill slices:
+//
+//	--input a.txt --input b.txt
+//
+// # Positional arguments
+//
+// Struct fields tagged with the arg option receive
+// positional arguments from the top-level object, in order.
+// A slice field tagged with the args option
+// receives all positional arguments after those.
+//
+//	type CopyOptions struct {
+//		Verbose bool     `shon:"verbose"`
+//		Dest    string   `shon:"dest,arg,required"`
+//		Sources []string `shon:"sources,args"`
+//	}
+//
+// Positional arguments may appear between keys.
+// A '--' argument in place of a key ends the keys of the object:
+// all arguments after it are positional and are taken verbatim
+// even if they start with '-'.
+// The above accepts:
+//
+//	out/ --verbose -t a.txt -- -b.txt
+//
+// Positional arguments are not accepted inside '[', ']',
+// and an argument that doesn't fit into any positional field
+// is an error.
+//
+// # Help
+//
+// ParseObject returns [ErrHelp] if a struct at the top level
+// receives '-h' or '--help' in place of a key.
+// Use [Usage] to build help text for it.
 func ParseObject(args []string, v any, opts ...ParseOption) error {
 	return Parse(args, v, append(opts, implicitObject(true))...)
 }
@@ -126,21 +346,30 @@ type parser struct {
 }
 
 func (p *parser) value() (value, error) {
+	pos := p.index()
 	arg, ok := p.next()
 	if !ok {
-		return _invalid, errors.New("expected a value")
+		return _invalid, p.eofError("expected a value")
 	}
-	return p.valueFrom(arg)
+	return p.valueFrom(pos, arg)
+}
+
+// valueFrom builds a value from arg,
+// which was found at index pos of the input.
+func (p *parser) valueFrom(pos int, arg string) (value, error) {
+	v, err := p.valueOf(pos, arg)
+	v.pos = pos
+	return v, err
 }
 
-func (p *parser) valueFrom(arg string) (value, error) {
+func (p *parser) valueOf(pos int, arg string) (value, error) {
 	switch arg {
 	case "":
 		return stringValue(arg), nil
 	case "[":
-		return p.arrayOrObject()
+		return p.arrayOrObject(pos)
 	case "]":
-		return _invalid, fmt.Errorf("expected a value, got %q", arg)
+		return _invalid, syntaxErrorf(pos, arg, "expected a value, got %q", arg)
 	case "[]":
 		return arrayValue(_emptyArray), nil
 	case "[--]":
@@ -152,14 +381,21 @@ func (p *parser) valueFrom(arg string) (value, error) {
 	case "--":
 		v, ok := p.next()
 		if !ok {
-			return _invalid, errors.New("unexpected end of input, expected a string")
+			return _invalid, p.eofError("expected a string")
 		}
-		return stringValue(v), nil
+		s := stringValue(v)
+		s.esc = true
+		return s, nil
 	}
 
 	numeric := isNumeric(arg)
 	if arg[0] == '-' && !numeric {
-		return _invalid, fmt.Errorf("unexpected flag %q", arg)
+		return _invalid, &SyntaxError{
+			Index:       pos,
+			Token:       arg,
+			Msg:         fmt.Sprintf("unexpected flag %q", arg),
+			Suggestions: suggestFlag(arg),
+		}
 	}
 
 	return value{
@@ -169,10 +405,12 @@ func (p *parser) valueFrom(arg string) (value, error) {
 	}, nil
 }
 
-func (p *parser) arrayOrObject() (value, error) {
+// arrayOrObject reads the array or object
+// that was opened by the '[' at index open.
+func (p *parser) arrayOrObject(open int) (value, error) {
 	arg, ok := p.peek()
 	if !ok {
-		return _invalid, errors.New("expected an array item, an object key, or ']'")
+		return _invalid, syntaxErrorf(open, "[", "expected an array item, an object key, or ']'")
 	}
 
 	if arg == "]" {
@@ -182,67 +420,141 @@ func (p *parser) arrayOrObject() (value, error) {
 	}
 
 	if arg != "--" && strings.HasPrefix(arg, "--") {
-		return p.object()
+		return objectValue(&cursorObjectReader{p: p, open: open}), nil
 	}
-	return arrayValue(&cursorArrayReader{p: p}), nil
+	return arrayValue(&cursorArrayReader{p: p, open: open}), nil
 }
 
+// object reads an object at the top level
+// that is not surrounded by '[', ']'.
 func (p *parser) object() (value, error) {
+	v := objectValue(&cursorObjectReader{p: p, open: -1})
+	v.pos = p.index()
 	// Need an error return to match the signature of value().
-	return objectValue(&cursorObjectReader{p: p}), nil
+	return v, nil
+}
+
+// objectPrefix reads an object at the top level
+// that ends at the first argument that isn't a key.
+func (p *parser) objectPrefix() (value, error) {
+	v := objectValue(&cursorObjectReader{p: p, open: -1, prefix: true})
+	v.pos = p.index()
+	return v, nil
 }
 
 type cursorArrayReader struct {
-	p *parser
+	p    *parser
+	open int // index of the opening '['
 
 	last struct {
 		arg string
+		pos int
 		ok  bool
 	}
+
+	// Set after more() returns false.
+	// Further calls to more() will not touch the cursor.
+	done bool
 }
 
 func (r *cursorArrayReader) more() bool {
+	if r.done {
+		return false
+	}
+	r.last.pos = r.p.index()
 	r.last.arg, r.last.ok = r.p.next()
-	return r.last.ok && r.last.arg != "]"
+	// If the input ends before the closing ']',
+	// report more items so that next() can report the error.
+	r.done = r.last.ok && r.last.arg == "]"
+	return !r.done
 }
 
 func (r *cursorArrayReader) next() (value, error) {
-	if r.last.ok {
-		return r.p.valueFrom(r.last.arg)
+	if !r.last.ok {
+		r.done = true
+		return _invalid, syntaxErrorf(r.open, "[", "unclosed '['")
 	}
-	return r.p.value()
+	return r.p.valueFrom(r.last.pos, r.last.arg)
 }
 
 type cursorObjectReader struct {
 	p *parser
 
+	// Index of the opening '[',
+	// or -1 for the top-level object that isn't surrounded by '[', ']'.
+	open int
+
 	last struct {
 		arg string
+		pos int
 		ok  bool
 	}
+
+	// Set after more() returns false.
+	// Further calls to more() will not touch the cursor.
+	done bool
+
+	// Whether the top-level object ends at the first argument
+	// that isn't a key, leaving it unconsumed.
+	prefix bool
+
+	// Whether positional arguments may appear between keys.
+	// See allowPositionals.
+	positionals bool
+
+	// Whether '-h' and '--help' are requests for help.
+	// See detectHelp.
+	helps bool
+
+	// Set after the '--' that ends the keys of the object.
+	// All arguments after it are positional.
+	terminated bool
 }
 
+var (
+	_ positionalReader = (*cursorObjectReader)(nil)
+	_ helpReader       = (*cursorObjectReader)(nil)
+)
+
 func (r *cursorObjectReader) more() bool {
+	if r.done {
+		return false
+	}
+	if r.prefix {
+		arg, ok := r.p.peek()
+		if !ok || arg == "--" || (!strings.HasPrefix(arg, "--") && !(r.helps && arg == "-h")) {
+			r.done = true
+			return false
+		}
+	}
+	r.last.pos = r.p.index()
 	r.last.arg, r.last.ok = r.p.next()
-	return r.last.ok && r.last.arg != "]"
-}
+	if r.open < 0 {
+		if r.positionals && !r.terminated && r.last.ok && r.last.arg == "--" {
+			r.terminated = true
+			r.last.pos = r.p.index()
+			r.last.arg, r.last.ok = r.p.next()
+		}
 
-func (r *cursorObjectReader) next() (string, value, error) {
-	var (
-		arg string
-		ok  bool
-	)
-	if r.last.ok {
-		arg, ok = r.last.arg, r.last.ok
+		// The top-level object ends with the input.
+		r.done = !r.last.ok
 	} else {
-		arg, ok = r.p.next()
+		// If the input ends before the closing ']',
+		// report more items so that next() can report the error.
+		r.done = r.last.ok && r.last.arg == "]"
 	}
-	if !ok {
-		return "", _invalid, errors.New("expected object key")
+	return !r.done
+}
+
+func (r *cursorObjectReader) next() (string, value, error) {
+	arg, pos := r.last.arg, r.last.pos
+	if !r.last.ok {
+		r.done = true
+		return "", _invalid, syntaxErrorf(r.open, "[", "unclosed '['")
 	}
 
 	if arg == "--" || !strings.HasPrefix(arg, "--") {
-		return "", _invalid, fmt.Errorf("expected object key, got %q", arg)
+		return "", _invalid, syntaxErrorf(pos, arg, "expected object key, got %q", arg)
 	}
 
 	key := arg[2:]
@@ -251,14 +563,57 @@ func (r *cursorObjectReader) next() (string, value, error) {
 		err   error
 	)
 	if idx := strings.IndexByte(key, '='); idx >= 0 {
-		value, err = r.p.valueFrom(key[idx+1:])
+		value, err = r.p.valueFrom(pos, key[idx+1:])
 		key = key[:idx]
 	} else {
 		value, err = r.p.value()
 	}
+	value.kpos = pos
 	return key, value, err
 }
 
+// allowPositionals allows positional arguments in the object
+// if it's the top-level object that isn't surrounded by '[', ']'
+// and doesn't end at the first of them.
+func (r *cursorObjectReader) allowPositionals() {
+	r.positionals = r.open < 0 && !r.prefix
+}
+
+// detectHelp recognizes requests for help in the object
+// if it's the top-level object that isn't surrounded by '[', ']'.
+func (r *cursorObjectReader) detectHelp() {
+	r.helps = r.open < 0
+}
+
+func (r *cursorObjectReader) help() bool {
+	if !r.helps || r.terminated || !r.last.ok {
+		return false
+	}
+	return r.last.arg == "-h" || r.last.arg == "--help"
+}
+
+func (r *cursorObjectReader) positional() bool {
+	if !r.positionals || !r.last.ok {
+		return false
+	}
+	arg := r.last.arg
+	return r.terminated || !strings.HasPrefix(arg, "--")
+}
+
+func (r *cursorObjectReader) nextPositional() (value, error) {
+	arg, pos := r.last.arg, r.last.pos
+	if r.terminated {
+		// Arguments after '--' are taken verbatim.
+		return value{
+			t:   scalarType,
+			s:   arg,
+			num: isNumeric(arg),
+			pos: pos,
+		}, nil
+	}
+	return r.p.valueFrom(pos, arg)
+}
+
 func isNumeric(s string) bool {
 	if len(s) == 0 {
 		return false
@@ -2,8 +2,13 @@ package shon
 
 import (
 	"fmt"
+	"log/slog"
+	"math/big"
+	"net/netip"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -160,6 +165,1355 @@ func TestParse(t *testing.T) {
 	}
 }
 
+func TestParse_textUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give []string
+		want any
+	}{
+		{
+			desc: "scalar",
+			give: []string{"192.168.1.1"},
+			want: netip.MustParseAddr("192.168.1.1"),
+		},
+		{
+			desc: "escaped string",
+			give: []string{"--", "DEBUG+2"},
+			want: slog.Level(-2),
+		},
+		{
+			desc: "numeric scalar",
+			give: []string{"12345678901234567890123"},
+			want: *mustBigInt("12345678901234567890123"),
+		},
+		{
+			desc: "pointer",
+			give: []string{"::1"},
+			want: ptrOf(netip.MustParseAddr("::1")),
+		},
+		{
+			desc: "struct field",
+			give: []string{"[", "--addr", "10.0.0.1", "--level", "WARN", "]"},
+			want: struct {
+				Addr  netip.Addr
+				Level slog.Level
+			}{
+				Addr:  netip.MustParseAddr("10.0.0.1"),
+				Level: slog.LevelWarn,
+			},
+		},
+		{
+			desc: "map key",
+			give: []string{"[", "--10.0.0.1", "foo", "--::1", "bar", "]"},
+			want: map[netip.Addr]string{
+				netip.MustParseAddr("10.0.0.1"): "foo",
+				netip.MustParseAddr("::1"):      "bar",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := reflect.New(reflect.TypeOf(tt.want))
+			require.NoError(t, Parse(tt.give, got.Interface()))
+			assert.Equal(t, tt.want, got.Elem().Interface())
+		})
+	}
+}
+
+func TestParse_time(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give []string
+		want any
+	}{
+		{
+			desc: "duration",
+			give: []string{"5s"},
+			want: 5 * time.Second,
+		},
+		{
+			desc: "duration/zero",
+			give: []string{"0"},
+			want: time.Duration(0),
+		},
+		{
+			desc: "duration/negative",
+			give: []string{"--", "-1h30m"},
+			want: -90 * time.Minute,
+		},
+		{
+			desc: "duration/slice",
+			give: []string{"[", "1ms", "2us", "]"},
+			want: []time.Duration{time.Millisecond, 2 * time.Microsecond},
+		},
+		{
+			desc: "time",
+			give: []string{"2006-01-02T15:04:05Z"},
+			want: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC),
+		},
+		{
+			desc: "time/fractional seconds",
+			give: []string{"2006-01-02T15:04:05.123Z"},
+			want: time.Date(2006, 1, 2, 15, 4, 5, 123_000_000, time.UTC),
+		},
+		{
+			desc: "struct/layout",
+			give: []string{
+				"[",
+				"--timeout", "30s",
+				"--when", "2023-12-24",
+				"--dates", "[", "2024-01-01", "2024-02-01", "]",
+				"--at", "2006-01-02T15:04:05Z",
+				"]",
+			},
+			want: struct {
+				Timeout time.Duration
+				When    time.Time   `shon:"when,layout=2006-01-02"`
+				Dates   []time.Time `shon:",layout=2006-01-02"`
+				At      *time.Time
+			}{
+				Timeout: 30 * time.Second,
+				When:    time.Date(2023, 12, 24, 0, 0, 0, 0, time.UTC),
+				Dates: []time.Time{
+					time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+					time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC),
+				},
+				At: ptrOf(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := reflect.New(reflect.TypeOf(tt.want))
+			require.NoError(t, Parse(tt.give, got.Interface()))
+			assert.Equal(t, tt.want, got.Elem().Interface())
+		})
+	}
+}
+
+func TestParseObject_defaults(t *testing.T) {
+	t.Parallel()
+
+	type item struct {
+		Name  string `shon:"name"`
+		Count int    `shon:"count" default:"1"`
+	}
+
+	type config struct {
+		Timeout time.Duration     `shon:"timeout" default:"5s"`
+		Tags    []string          `shon:"tags" default:"[ a b ]"`
+		Title   string            `shon:"title" default:"'hello world'"`
+		Labels  map[string]string `shon:"labels" default:"[ --env prod ]"`
+		Verbose *bool             `shon:"verbose" default:"-t"`
+		Item    item              `shon:"item"`
+		Items   []item            `shon:"items"`
+		Extra   *item             `shon:"extra"`
+		Other   int               `shon:"other"`
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		want config
+	}{
+		{
+			desc: "empty",
+			want: config{
+				Timeout: 5 * time.Second,
+				Tags:    []string{"a", "b"},
+				Title:   "hello world",
+				Labels:  map[string]string{"env": "prod"},
+				Verbose: ptrOf(true),
+				Item:    item{Count: 1},
+			},
+		},
+		{
+			desc: "overrides",
+			give: []string{
+				"--timeout", "1m",
+				"--tags", "[]",
+				"--title", "",
+				"--labels", "-n",
+				"--verbose", "-f",
+				"--item", "[", "--count", "2", "]",
+			},
+			want: config{
+				Timeout: time.Minute,
+				Tags:    []string{},
+				Labels:  nil,
+				Verbose: ptrOf(false),
+				Item:    item{Count: 2},
+			},
+		},
+		{
+			desc: "nested",
+			give: []string{
+				"--item", "[", "--name", "foo", "]",
+				"--items", "[",
+				"[", "--name", "a", "]",
+				"[", "--name", "b", "--count", "3", "]",
+				"]",
+				"--extra", "[--]",
+			},
+			want: config{
+				Timeout: 5 * time.Second,
+				Tags:    []string{"a", "b"},
+				Title:   "hello world",
+				Labels:  map[string]string{"env": "prod"},
+				Verbose: ptrOf(true),
+				Item:    item{Name: "foo", Count: 1},
+				Items: []item{
+					{Name: "a", Count: 1},
+					{Name: "b", Count: 3},
+				},
+				Extra: &item{Count: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got config
+			require.NoError(t, ParseObject(tt.give, &got))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("not shared", func(t *testing.T) {
+		t.Parallel()
+
+		var a, b config
+		require.NoError(t, ParseObject(nil, &a))
+		require.NoError(t, ParseObject(nil, &b))
+
+		a.Tags[0] = "x"
+		a.Labels["env"] = "dev"
+		assert.Equal(t, []string{"a", "b"}, b.Tags)
+		assert.Equal(t, map[string]string{"env": "prod"}, b.Labels)
+	})
+}
+
+func TestParseObject_merge(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `shon:"host"`
+		Port int    `shon:"port" default:"80"`
+	}
+
+	type config struct {
+		Name    string            `shon:"name" default:"foo"`
+		Env     string            `shon:"env,required"`
+		Tags    []string          `shon:"tags"`
+		Labels  map[string]string `shon:"labels"`
+		Server  server            `shon:"server"`
+		Servers map[string]server `shon:"servers"`
+		Backup  *server           `shon:"backup"`
+		Extra   any               `shon:"extra"`
+		Version testVersion       `shon:"version"`
+	}
+
+	base := func() config {
+		return config{
+			Name:   "base",
+			Env:    "prod",
+			Tags:   []string{"a", "b"},
+			Labels: map[string]string{"team": "x", "tier": "1"},
+			Server: server{Host: "example.com", Port: 8080},
+			Servers: map[string]server{
+				"eu": {Host: "eu.example.com", Port: 1},
+			},
+			Backup:  &server{Host: "backup.example.com", Port: 2},
+			Extra:   map[string]any{"a": 1, "b": map[string]any{"c": 2}},
+			Version: testVersion{Major: 1, Minor: 2},
+		}
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		opts []ParseOption
+		want func(*config)
+	}{
+		{
+			desc: "empty",
+			want: func(*config) {},
+		},
+		{
+			desc: "scalars",
+			give: []string{"--env", "dev"},
+			want: func(c *config) { c.Env = "dev" },
+		},
+		{
+			desc: "replace slice",
+			give: []string{"--tags", "[", "c", "]"},
+			want: func(c *config) { c.Tags = []string{"c"} },
+		},
+		{
+			desc: "append slice",
+			give: []string{"--tags", "[", "c", "]"},
+			opts: []ParseOption{AppendSlices(true)},
+			want: func(c *config) { c.Tags = []string{"a", "b", "c"} },
+		},
+		{
+			desc: "null slice",
+			give: []string{"--tags", "-n"},
+			opts: []ParseOption{AppendSlices(true)},
+			want: func(c *config) { c.Tags = nil },
+		},
+		{
+			desc: "map",
+			give: []string{"--labels", "[", "--tier", "2", "--new", "y", "]"},
+			want: func(c *config) {
+				c.Labels = map[string]string{"team": "x", "tier": "2", "new": "y"}
+			},
+		},
+		{
+			desc: "nested struct",
+			give: []string{"--server", "[", "--port", "9090", "]"},
+			want: func(c *config) { c.Server.Port = 9090 },
+		},
+		{
+			desc: "map of structs",
+			give: []string{
+				"--servers", "[",
+				"--eu", "[", "--port", "3", "]",
+				"--us", "[", "--host", "us.example.com", "]",
+				"]",
+			},
+			want: func(c *config) {
+				c.Servers = map[string]server{
+					"eu": {Host: "eu.example.com", Port: 3},
+					"us": {Host: "us.example.com", Port: 80},
+				}
+			},
+		},
+		{
+			desc: "pointer",
+			give: []string{"--backup", "[", "--port", "3", "]"},
+			want: func(c *config) { c.Backup.Port = 3 },
+		},
+		{
+			desc: "null pointer",
+			give: []string{"--backup", "-n"},
+			want: func(c *config) { c.Backup = nil },
+		},
+		{
+			desc: "any map",
+			give: []string{"--extra", "[", "--b", "[", "--d", "3", "]", "]"},
+			want: func(c *config) {
+				c.Extra = map[string]any{"a": 1, "b": map[string]any{"c": 2, "d": 3}}
+			},
+		},
+		{
+			desc: "any replaced",
+			give: []string{"--extra", "[", "1", "]"},
+			want: func(c *config) { c.Extra = []any{1} },
+		},
+		{
+			desc: "unmarshaler",
+			give: []string{"--version", "3.4"},
+			want: func(c *config) { c.Version = testVersion{Major: 3, Minor: 4} },
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			want := base()
+			tt.want(&want)
+
+			got := base()
+			opts := append([]ParseOption{Merge(true)}, tt.opts...)
+			require.NoError(t, ParseObject(tt.give, &got, opts...))
+			assert.Equal(t, want, got)
+		})
+	}
+
+	t.Run("does not modify maps and slices", func(t *testing.T) {
+		t.Parallel()
+
+		got := base()
+		labels, tags := got.Labels, got.Tags
+		give := []string{"--labels", "[", "--team", "y", "]", "--tags", "[", "c", "]"}
+		require.NoError(t, ParseObject(give, &got, Merge(true), AppendSlices(true)))
+
+		assert.Equal(t, map[string]string{"team": "x", "tier": "1"}, labels)
+		assert.Equal(t, []string{"a", "b"}, tags)
+	})
+
+	t.Run("pointer in any", func(t *testing.T) {
+		t.Parallel()
+
+		srv := &server{Host: "a", Port: 1}
+		got := config{Env: "prod", Extra: srv}
+		require.NoError(t, ParseObject([]string{"--extra", "[", "--port", "2", "]"}, &got, Merge(true)))
+		assert.Same(t, srv, got.Extra)
+		assert.Equal(t, &server{Host: "a", Port: 2}, srv)
+	})
+
+	t.Run("struct in any", func(t *testing.T) {
+		t.Parallel()
+
+		got := config{Env: "prod", Extra: server{Host: "a", Port: 1}}
+		require.NoError(t, ParseObject([]string{"--extra", "[", "--port", "2", "]"}, &got, Merge(true)))
+		assert.Equal(t, server{Host: "a", Port: 2}, got.Extra)
+	})
+
+	t.Run("typed slice in any", func(t *testing.T) {
+		t.Parallel()
+
+		got := config{Env: "prod", Extra: []string{"a"}}
+		give := []string{"--extra", "[", "b", "]"}
+		require.NoError(t, ParseObject(give, &got, Merge(true), AppendSlices(true)))
+		assert.Equal(t, []string{"a", "b"}, got.Extra)
+
+		require.NoError(t, ParseObject(give, &got, Merge(true)))
+		assert.Equal(t, []string{"b"}, got.Extra)
+	})
+
+	t.Run("any replaced by other shape", func(t *testing.T) {
+		t.Parallel()
+
+		got := config{Env: "prod", Extra: []string{"a"}}
+		require.NoError(t, ParseObject([]string{"--extra", "[", "--b", "1", "]"}, &got, Merge(true)))
+		assert.Equal(t, map[string]any{"b": 1}, got.Extra)
+	})
+
+	t.Run("error leaves pointers as-is", func(t *testing.T) {
+		t.Parallel()
+
+		got := base()
+		backup := got.Backup
+		srv := &server{Host: "a", Port: 1}
+		got.Extra = srv
+
+		give := []string{
+			"--backup", "[", "--host", "changed", "]",
+			"--extra", "[", "--port", "2", "]",
+			"--name", "[", "]",
+		}
+		require.Error(t, ParseObject(give, &got, Merge(true), AllErrors(true)))
+		assert.Equal(t, base().Backup, backup)
+		assert.Equal(t, &server{Host: "a", Port: 1}, srv)
+	})
+
+	t.Run("repeated pointer", func(t *testing.T) {
+		t.Parallel()
+
+		got := base()
+		backup := got.Backup
+		give := []string{
+			"--backup", "[", "--host", "changed", "]",
+			"--backup", "[", "--port", "3", "]",
+		}
+		require.NoError(t, ParseObject(give, &got, Merge(true)))
+		assert.Same(t, backup, got.Backup)
+		assert.Equal(t, &server{Host: "changed", Port: 3}, backup)
+	})
+
+	t.Run("required", func(t *testing.T) {
+		t.Parallel()
+
+		var got config
+		err := ParseObject([]string{"--name", "foo"}, &got, Merge(true))
+		var missingErr *MissingFieldsError
+		require.ErrorAs(t, err, &missingErr)
+		assert.Equal(t, []string{".env"}, missingErr.Paths)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		t.Parallel()
+
+		got := base()
+		require.NoError(t, ParseObject([]string{"--env", "dev"}, &got))
+		assert.Equal(t, config{Name: "foo", Env: "dev", Server: server{Port: 80}}, got)
+	})
+}
+
+func TestParseObject_duplicateKeys(t *testing.T) {
+	t.Parallel()
+
+	type config struct {
+		Name   string              `shon:"name"`
+		Inputs []string            `shon:"input"`
+		Pairs  [][]int             `shon:"pair"`
+		Env    map[string][]string `shon:"env"`
+		Extra  any                 `shon:"extra"`
+		Count  int
+	}
+
+	tests := []struct {
+		desc    string
+		give    []string
+		policy  DuplicateKeyPolicy
+		want    config
+		wantErr string
+	}{
+		{
+			desc:   "last wins",
+			give:   []string{"--name", "a", "--name", "b", "--input", "[", "x", "]", "--input", "[", "y", "]"},
+			policy: DuplicateLastWins,
+			want:   config{Name: "b", Inputs: []string{"y"}},
+		},
+		{
+			desc:   "first wins",
+			give:   []string{"--name", "a", "--name", "[", "b", "]", "--env", "[", "--x", "[", "1", "]", "--x", "-t", "]"},
+			policy: DuplicateFirstWins,
+			want:   config{Name: "a", Env: map[string][]string{"x": {"1"}}},
+		},
+		{
+			desc:    "error",
+			give:    []string{"--name", "a", "--input", "[]", "--name", "b"},
+			policy:  DuplicateError,
+			wantErr: `argument 4 (.name): duplicate key "name"`,
+		},
+		{
+			desc:    "error/alias",
+			give:    []string{"--count", "1", "--Count", "2"},
+			policy:  DuplicateError,
+			wantErr: `argument 2 (.Count): duplicate key "Count"`,
+		},
+		{
+			desc:    "error/map",
+			give:    []string{"--env", "[", "--x", "[]", "--y", "[]", "--x", "[]", "]"},
+			policy:  DuplicateError,
+			wantErr: `argument 6 (.env.x): duplicate key "x"`,
+		},
+		{
+			desc:    "error/any",
+			give:    []string{"--extra", "[", "--x", "1", "--x", "2", "]"},
+			policy:  DuplicateError,
+			wantErr: `argument 4 (.extra.x): duplicate key "x"`,
+		},
+		{
+			desc: "accumulate",
+			give: []string{
+				"--name", "a", "--name", "b",
+				"--input", "x", "--input", "[", "y", "z", "]", "--input", "w",
+				"--pair", "[", "1", "2", "]", "--pair", "[", "3", "]",
+				"--env", "[", "--x", "1", "--y", "2", "--x", "[", "3", "4", "]", "]",
+			},
+			policy: DuplicateAccumulate,
+			want: config{
+				Name:   "b",
+				Inputs: []string{"x", "y", "z", "w"},
+				Pairs:  [][]int{{1, 2}, {3}},
+				Env: map[string][]string{
+					"x": {"1", "3", "4"},
+					"y": {"2"},
+				},
+			},
+		},
+		{
+			desc: "accumulate/any",
+			give: []string{
+				"--extra", "[",
+				"--a", "1",
+				"--b", "x", "--b", "[", "y", "]", "--b", "-t",
+				"--c", "[", "--d", "1", "]", "--c", "[", "--d", "2", "]",
+				"]",
+			},
+			policy: DuplicateAccumulate,
+			want: config{
+				Extra: map[string]any{
+					"a": 1,
+					"b": []any{"x", []any{"y"}, true},
+					"c": []any{
+						map[string]any{"d": 1},
+						map[string]any{"d": 2},
+					},
+				},
+			},
+		},
+		{
+			desc:   "accumulate/leading null",
+			give:   []string{"--input", "-n", "--input", "x"},
+			policy: DuplicateAccumulate,
+			want:   config{Inputs: []string{"x"}},
+		},
+		{
+			desc:   "accumulate/trailing null",
+			give:   []string{"--input", "x", "--input", "-n", "--env", "[", "--x", "-n", "]"},
+			policy: DuplicateAccumulate,
+			want:   config{Inputs: []string{"x"}, Env: map[string][]string{"x": nil}},
+		},
+		{
+			desc:   "accumulate/null items",
+			give:   []string{"--pair", "[", "1", "]", "--pair", "-n"},
+			policy: DuplicateAccumulate,
+			want:   config{Pairs: [][]int{{1}, nil}},
+		},
+		{
+			desc:   "accumulate/any field",
+			give:   []string{"--extra", "a", "--extra", "[", "b", "]", "--extra", "-t"},
+			policy: DuplicateAccumulate,
+			want:   config{Extra: []any{"a", []any{"b"}, true}},
+		},
+		{
+			desc:   "accumulate/any field once",
+			give:   []string{"--extra", "[", "a", "]"},
+			policy: DuplicateAccumulate,
+			want:   config{Extra: []any{"a"}},
+		},
+		{
+			desc:    "accumulate/bad item",
+			give:    []string{"--pair", "[", "1", "]", "--pair", "2"},
+			policy:  DuplicateAccumulate,
+			wantErr: `argument 5 (.pair): expected []int, got scalar`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got config
+			err := ParseObject(tt.give, &got, DuplicateKeys(tt.policy))
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("accumulate/merge", func(t *testing.T) {
+		t.Parallel()
+
+		give := []string{"--input", "c", "--input", "d"}
+		opts := []ParseOption{DuplicateKeys(DuplicateAccumulate), Merge(true)}
+
+		got := config{Inputs: []string{"a", "b"}}
+		require.NoError(t, ParseObject(give, &got, opts...))
+		assert.Equal(t, []string{"c", "d"}, got.Inputs)
+
+		got = config{Inputs: []string{"a", "b"}}
+		require.NoError(t, ParseObject(give, &got, append(opts, AppendSlices(true))...))
+		assert.Equal(t, []string{"a", "b", "c", "d"}, got.Inputs)
+	})
+
+	t.Run("all errors", func(t *testing.T) {
+		t.Parallel()
+
+		give := []string{"--name", "a", "--name", "b", "--name", "c"}
+
+		var got config
+		err := ParseObject(give, &got, DuplicateKeys(DuplicateError), AllErrors(true))
+		assert.EqualError(t, err, strings.Join([]string{
+			`argument 2 (.name): duplicate key "name"`,
+			`argument 4 (.name): duplicate key "name"`,
+		}, "\n"))
+	})
+}
+
+// CommonOptions is embedded in structs in TestParseObject_embedded.
+// It must be exported to be embedded as a pointer.
+type CommonOptions struct {
+	Verbose bool   `shon:"verbose"`
+	Output  string `shon:"output" default:"-"`
+}
+
+func TestParseObject_embedded(t *testing.T) {
+	t.Parallel()
+
+	type build struct {
+		CommonOptions
+		Target string `shon:"target,required"`
+	}
+
+	type buildPtr struct {
+		*CommonOptions
+		Target string `shon:"target"`
+	}
+
+	type nested struct {
+		CommonOptions `shon:"common"`
+		Output        int `shon:"output"`
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		into any
+		want any
+	}{
+		{
+			desc: "embedded",
+			give: []string{"--verbose", "-t", "--target", "foo"},
+			into: &build{},
+			want: &build{
+				CommonOptions: CommonOptions{Verbose: true, Output: "-"},
+				Target:        "foo",
+			},
+		},
+		{
+			desc: "embedded pointer",
+			give: []string{"--target", "foo", "--output", "out.txt"},
+			into: &buildPtr{},
+			want: &buildPtr{
+				CommonOptions: &CommonOptions{Output: "out.txt"},
+				Target:        "foo",
+			},
+		},
+		{
+			desc: "embedded pointer with defaults",
+			give: []string{"--target", "foo"},
+			into: &buildPtr{},
+			want: &buildPtr{
+				CommonOptions: &CommonOptions{Output: "-"},
+				Target:        "foo",
+			},
+		},
+		{
+			desc: "named tag",
+			give: []string{"--common", "[", "--verbose", "-t", "]", "--output", "42"},
+			into: &nested{},
+			want: &nested{
+				CommonOptions: CommonOptions{Verbose: true, Output: "-"},
+				Output:        42,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			require.NoError(t, ParseObject(tt.give, tt.into))
+			assert.Equal(t, tt.want, tt.into)
+		})
+	}
+
+	t.Run("merge into embedded pointer", func(t *testing.T) {
+		t.Parallel()
+
+		common := &CommonOptions{Verbose: true}
+		got := buildPtr{CommonOptions: common}
+		require.NoError(t, ParseObject([]string{"--output", "x"}, &got, Merge(true)))
+		assert.Same(t, common, got.CommonOptions)
+		assert.Equal(t, &CommonOptions{Verbose: true, Output: "x"}, common)
+	})
+
+	t.Run("required promoted field", func(t *testing.T) {
+		t.Parallel()
+
+		var got build
+		err := ParseObject([]string{"--verbose", "-t"}, &got)
+		var missingErr *MissingFieldsError
+		require.ErrorAs(t, err, &missingErr)
+		assert.Equal(t, []string{".target"}, missingErr.Paths)
+	})
+}
+
+func TestParseObject_remaining(t *testing.T) {
+	t.Parallel()
+
+	type plugin struct {
+		Name  string         `shon:"name"`
+		Extra map[string]any `shon:",remaining"`
+	}
+
+	tests := []struct {
+		desc    string
+		give    []string
+		opts    []ParseOption
+		want    plugin
+		wantErr string
+	}{
+		{
+			desc: "no extra",
+			give: []string{"--name", "foo"},
+			want: plugin{Name: "foo"},
+		},
+		{
+			desc: "extra",
+			give: []string{
+				"--verbose", "-t",
+				"--name", "foo",
+				"--level=3",
+				"--tags", "[", "a", "b", "]",
+				"--opts", "[", "--x", "y", "]",
+			},
+			want: plugin{
+				Name: "foo",
+				Extra: map[string]any{
+					"verbose": true,
+					"level":   3,
+					"tags":    []any{"a", "b"},
+					"opts":    map[string]any{"x": "y"},
+				},
+			},
+		},
+		{
+			desc: "use number",
+			give: []string{"--level", "3"},
+			opts: []ParseOption{UseNumber(true)},
+			want: plugin{Extra: map[string]any{"level": Number("3")}},
+		},
+		{
+			desc:    "duplicate",
+			give:    []string{"--x", "1", "--x", "2"},
+			opts:    []ParseOption{DuplicateKeys(DuplicateError)},
+			wantErr: `argument 2 (.x): duplicate key "x"`,
+		},
+		{
+			desc: "first wins",
+			give: []string{"--x", "1", "--x", "2"},
+			opts: []ParseOption{DuplicateKeys(DuplicateFirstWins)},
+			want: plugin{Extra: map[string]any{"x": 1}},
+		},
+		{
+			desc: "accumulate",
+			give: []string{"--y", "a", "--x", "1", "--y", "b", "--y", "[", "c", "]"},
+			opts: []ParseOption{DuplicateKeys(DuplicateAccumulate)},
+			want: plugin{Extra: map[string]any{"x": 1, "y": []any{"a", "b", []any{"c"}}}},
+		},
+		{
+			desc: "merge",
+			give: []string{"--x", "1"},
+			opts: []ParseOption{Merge(true)},
+			want: plugin{Name: "old", Extra: map[string]any{"x": 1, "y": 2}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := plugin{Name: "old", Extra: map[string]any{"y": 2}}
+			err := ParseObject(tt.give, &got, tt.opts...)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("typed values", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Name  string         `shon:"name"`
+			Ports map[string]int `shon:",remaining"`
+		}
+		err := ParseObject([]string{"--http", "80", "--https", "x"}, &got)
+		assert.EqualError(t, err, `argument 3 (.https): bad int: strconv.ParseInt: parsing "x": invalid syntax`)
+	})
+}
+
+func TestParseObject_passthrough(t *testing.T) {
+	t.Parallel()
+
+	type wrapper struct {
+		Verbose bool     `shon:"verbose"`
+		Rest    []string `shon:",passthrough"`
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		opts []ParseOption
+		want wrapper
+	}{
+		{
+			desc: "none",
+			give: []string{"--verbose", "-t"},
+			want: wrapper{Verbose: true},
+		},
+		{
+			desc: "spans",
+			give: []string{
+				"--a", "1",
+				"--verbose", "-t",
+				"--b=x",
+				"--c", "[", "--d", "[", "1", "2", "]", "--e", "[]", "]",
+				"--f", "--", "-g",
+				"--a", "2",
+			},
+			want: wrapper{
+				Verbose: true,
+				Rest: []string{
+					"--a", "1",
+					"--b=x",
+					"--c", "[", "--d", "[", "1", "2", "]", "--e", "[]", "]",
+					"--f", "--", "-g",
+					"--a", "2",
+				},
+			},
+		},
+		{
+			desc: "merge",
+			give: []string{"--b", "2"},
+			opts: []ParseOption{Merge(true)},
+			want: wrapper{Rest: []string{"--b", "2"}},
+		},
+		{
+			desc: "merge and append",
+			give: []string{"--b", "2"},
+			opts: []ParseOption{Merge(true), AppendSlices(true)},
+			want: wrapper{Rest: []string{"--a", "1", "--b", "2"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := wrapper{Rest: []string{"--a", "1"}}
+			require.NoError(t, ParseObject(tt.give, &got, tt.opts...))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Child wrapper `shon:"child"`
+		}
+		give := []string{"--child", "[", "--x", "[", "a", "]", "--verbose", "-f", "]"}
+		require.NoError(t, ParseObject(give, &got))
+		assert.Equal(t, []string{"--x", "[", "a", "]"}, got.Child.Rest)
+	})
+
+	t.Run("syntax error", func(t *testing.T) {
+		t.Parallel()
+
+		var got wrapper
+		err := ParseObject([]string{"--x", "[", "a"}, &got)
+		var syntaxErr *SyntaxError
+		require.ErrorAs(t, err, &syntaxErr)
+		assert.Equal(t, 1, syntaxErr.Index)
+	})
+
+	t.Run("does not alias input", func(t *testing.T) {
+		t.Parallel()
+
+		give := []string{"--x", "1"}
+		var got wrapper
+		require.NoError(t, ParseObject(give, &got))
+		got.Rest[0] = "--y"
+		assert.Equal(t, []string{"--x", "1"}, give)
+	})
+}
+
+func mustBigInt(s string) *big.Int {
+	i, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		panic(fmt.Sprintf("bad big.Int %q", s))
+	}
+	return i
+}
+
+func TestParseObject_positional(t *testing.T) {
+	t.Parallel()
+
+	type copyOptions struct {
+		Verbose bool     `shon:"verbose"`
+		Dest    string   `shon:"dest,arg"`
+		Sources []string `shon:"sources,args"`
+	}
+
+	tests := []struct {
+		desc string
+		give []string
+		opts []ParseOption
+		want copyOptions
+	}{
+		{
+			desc: "empty",
+			want: copyOptions{},
+		},
+		{
+			desc: "only keys",
+			give: []string{"--verbose", "-t"},
+			want: copyOptions{Verbose: true},
+		},
+		{
+			desc: "interleaved",
+			give: []string{"out", "--verbose", "-t", "a.txt", "b.txt"},
+			want: copyOptions{
+				Verbose: true,
+				Dest:    "out",
+				Sources: []string{"a.txt", "b.txt"},
+			},
+		},
+		{
+			desc: "terminator",
+			give: []string{"--verbose", "-t", "--", "--out", "-a", "--", "[", "42"},
+			want: copyOptions{
+				Verbose: true,
+				Dest:    "--out",
+				Sources: []string{"-a", "--", "[", "42"},
+			},
+		},
+		{
+			desc: "terminator only",
+			give: []string{"--"},
+			want: copyOptions{},
+		},
+		{
+			desc: "array of sources",
+			give: []string{"out", "[", "a", "b", "]", "c"},
+			want: copyOptions{Dest: "out", Sources: []string{"a", "b", "c"}},
+		},
+		{
+			desc: "merge",
+			give: []string{"out", "c"},
+			opts: []ParseOption{Merge(true)},
+			want: copyOptions{Dest: "out", Sources: []string{"c"}},
+		},
+		{
+			desc: "merge and append",
+			give: []string{"out", "c"},
+			opts: []ParseOption{Merge(true), AppendSlices(true)},
+			want: copyOptions{Dest: "out", Sources: []string{"a", "c"}},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got copyOptions
+			if len(tt.opts) > 0 {
+				got.Sources = []string{"a"}
+			}
+			require.NoError(t, ParseObject(tt.give, &got, tt.opts...))
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	t.Run("typed", func(t *testing.T) {
+		t.Parallel()
+
+		type options struct {
+			Count int            `shon:"count,arg"`
+			Rate  *float64       `shon:"rate,arg"`
+			Rest  []any          `shon:"rest,args"`
+			Extra map[string]any `shon:",remaining"`
+		}
+
+		var got options
+		// The '--' after a key escapes its value.
+		give := []string{"3", "--x", "--", "-y", "0.5", "-t", "[", "1", "]", "--", "-n"}
+		require.NoError(t, ParseObject(give, &got))
+		assert.Equal(t, options{
+			Count: 3,
+			Rate:  ptrOf(0.5),
+			Rest:  []any{true, []any{1}, "-n"},
+			Extra: map[string]any{"x": "-y"},
+		}, got)
+	})
+
+	t.Run("required", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Src  string `shon:"src,arg,required"`
+			Dest string `shon:"dest,arg,required"`
+		}
+		err := ParseObject([]string{"a"}, &got)
+		assert.EqualError(t, err, "argument 0: missing required field .dest")
+	})
+
+	t.Run("embedded", func(t *testing.T) {
+		t.Parallel()
+
+		type inputs struct {
+			Input string `shon:"input,arg"`
+		}
+		var got struct {
+			inputs
+			Output string `shon:"output,arg"`
+		}
+		require.NoError(t, ParseObject([]string{"a", "b"}, &got))
+		assert.Equal(t, "a", got.Input)
+		assert.Equal(t, "b", got.Output)
+	})
+
+	t.Run("not a key", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Dest string `shon:"dest,arg"`
+		}
+		err := ParseObject([]string{"--dest", "x"}, &got)
+		assert.EqualError(t, err, `argument 0 (.dest): unknown field "dest"`)
+	})
+}
+
+func TestParseObject_enum(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Level  string  `shon:"level" enum:"debug info 'very loud'"`
+		Codes  []int   `shon:"codes" enum:"200 404"`
+		Format *string `shon:"format" enum:"json text"`
+	}
+
+	t.Run("valid", func(t *testing.T) {
+		t.Parallel()
+
+		var got options
+		require.NoError(t, ParseObject([]string{
+			"--level", "very loud",
+			"--codes", "[", "404", "200", "]",
+			"--format", "text",
+		}, &got))
+		assert.Equal(t, options{
+			Level:  "very loud",
+			Codes:  []int{404, 200},
+			Format: ptrOf("text"),
+		}, got)
+	})
+
+	t.Run("null pointer", func(t *testing.T) {
+		t.Parallel()
+
+		got := options{Format: ptrOf("json")}
+		require.NoError(t, ParseObject([]string{"--format", "-n"}, &got))
+		assert.Nil(t, got.Format)
+	})
+
+	tests := []struct {
+		desc    string
+		give    []string
+		wantErr string
+	}{
+		{
+			desc:    "unknown value",
+			give:    []string{"--level", "debgu"},
+			wantErr: `argument 1 (.level): unexpected value "debgu": must be one of debug, info, very loud; did you mean "debug"?`,
+		},
+		{
+			desc:    "escaped string",
+			give:    []string{"--level", "--", "warn"},
+			wantErr: `argument 1 (.level): unexpected value "warn": must be one of debug, info, very loud`,
+		},
+		{
+			desc:    "element",
+			give:    []string{"--codes", "[", "200", "500", "]"},
+			wantErr: `argument 3 (.codes[1]): unexpected value "500": must be one of 200, 404; did you mean "200"?`,
+		},
+		{
+			desc:    "pointer",
+			give:    []string{"--format", "yaml"},
+			wantErr: `argument 1 (.format): unexpected value "yaml": must be one of json, text`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got options
+			err := ParseObject(tt.give, &got)
+			assert.EqualError(t, err, tt.wantErr)
+
+			var decErr *DecodeError
+			require.ErrorAs(t, err, &decErr)
+		})
+	}
+}
+
+func TestParsePrefix(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc     string
+		give     []string
+		into     any
+		want     any
+		wantRest []string
+	}{
+		{
+			desc:     "scalar",
+			give:     []string{"42", "foo", "bar"},
+			into:     int(0),
+			want:     42,
+			wantRest: []string{"foo", "bar"},
+		},
+		{
+			desc:     "array",
+			give:     []string{"[", "a", "b", "]", "c"},
+			into:     []string(nil),
+			want:     []string{"a", "b"},
+			wantRest: []string{"c"},
+		},
+		{
+			desc:     "escaped",
+			give:     []string{"--", "-x", "-y"},
+			into:     "",
+			want:     "-x",
+			wantRest: []string{"-y"},
+		},
+		{
+			desc:     "everything",
+			give:     []string{"[", "--a", "1", "]"},
+			into:     map[string]int(nil),
+			want:     map[string]int{"a": 1},
+			wantRest: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got := reflect.New(reflect.TypeOf(tt.into))
+			rest, err := ParsePrefix(tt.give, got.Interface())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got.Elem().Interface())
+			assert.Equal(t, tt.wantRest, rest)
+		})
+	}
+
+	t.Run("empty", func(t *testing.T) {
+		t.Parallel()
+
+		var got int
+		_, err := ParsePrefix(nil, &got)
+		assert.EqualError(t, err, "unexpected end of input: expected a value")
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		got := 42
+		rest, err := ParsePrefix([]string{"x", "y"}, &got)
+		assert.EqualError(t, err, `argument 0: bad int: strconv.ParseInt: parsing "x": invalid syntax`)
+		assert.Nil(t, rest)
+		assert.Equal(t, 42, got, "must not be modified")
+	})
+}
+
+func TestParseObjectPrefix(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Verbose bool     `shon:"verbose"`
+		Tags    []string `shon:"tags"`
+		File    string   `shon:"file,arg"`
+	}
+
+	tests := []struct {
+		desc     string
+		give     []string
+		want     options
+		wantRest []string
+	}{
+		{
+			desc: "empty",
+		},
+		{
+			desc: "keys only",
+			give: []string{"--verbose", "-t", "--tags", "[", "a", "]"},
+			want: options{Verbose: true, Tags: []string{"a"}},
+		},
+		{
+			desc:     "positional",
+			give:     []string{"--verbose=-t", "build", "--tags", "[", "a", "]"},
+			want:     options{Verbose: true},
+			wantRest: []string{"build", "--tags", "[", "a", "]"},
+		},
+		{
+			desc:     "escaped value",
+			give:     []string{"--tags", "--", "-x", "y"},
+			want:     options{Tags: []string{"-x"}},
+			wantRest: []string{"y"},
+		},
+		{
+			desc:     "terminator",
+			give:     []string{"--verbose", "-t", "--", "--tags", "x"},
+			want:     options{Verbose: true},
+			wantRest: []string{"--", "--tags", "x"},
+		},
+		{
+			desc:     "flag",
+			give:     []string{"-t", "foo"},
+			wantRest: []string{"-t", "foo"},
+		},
+		{
+			desc:     "array",
+			give:     []string{"[", "--verbose", "-t", "]"},
+			wantRest: []string{"[", "--verbose", "-t", "]"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got options
+			rest, err := ParseObjectPrefix(tt.give, &got, DuplicateKeys(DuplicateAccumulate))
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			if len(tt.wantRest) == 0 {
+				assert.Empty(t, rest)
+			} else {
+				assert.Equal(t, tt.wantRest, rest)
+			}
+		})
+	}
+
+	t.Run("global options", func(t *testing.T) {
+		t.Parallel()
+
+		args := []string{"--verbose", "-t", "run", "--name", "foo", "a.txt"}
+
+		var global struct {
+			Verbose bool `shon:"verbose"`
+		}
+		rest, err := ParseObjectPrefix(args, &global)
+		require.NoError(t, err)
+		require.Equal(t, []string{"run", "--name", "foo", "a.txt"}, rest)
+
+		cmd, err := ParsePrefix(rest, new(string))
+		require.NoError(t, err)
+
+		var opts struct {
+			Name string `shon:"name"`
+			File string `shon:"file,arg"`
+		}
+		require.NoError(t, ParseObject(cmd, &opts))
+		assert.True(t, global.Verbose)
+		assert.Equal(t, "foo", opts.Name)
+		assert.Equal(t, "a.txt", opts.File)
+	})
+}
+
 func TestParseAny(t *testing.T) {
 	t.Parallel()
 
@@ -533,6 +1887,89 @@ func TestParse_decodeErrors(t *testing.T) {
 			into:    struct{}{},
 			wantErr: "expected struct {}, got array",
 		},
+		{
+			desc:    "bad text",
+			give:    []string{"foo"},
+			into:    netip.Addr{},
+			wantErr: "bad netip.Addr: ParseAddr",
+		},
+		{
+			desc:    "unexpected text",
+			give:    []string{"-t"},
+			into:    netip.Addr{},
+			wantErr: "expected netip.Addr, got bool",
+		},
+		{
+			desc:    "bad text key",
+			give:    []string{"[", "--foo", "bar", "]"},
+			into:    map[netip.Addr]string{},
+			wantErr: "bad netip.Addr",
+		},
+		{
+			desc:    "bad duration",
+			give:    []string{"5000000000"},
+			into:    time.Duration(0),
+			wantErr: "bad time.Duration: time: missing unit",
+		},
+		{
+			desc:    "unexpected duration",
+			give:    []string{"[]"},
+			into:    time.Duration(0),
+			wantErr: "expected time.Duration, got array",
+		},
+		{
+			desc:    "bad time",
+			give:    []string{"2006-01-02"},
+			into:    time.Time{},
+			wantErr: "bad time.Time: parsing time",
+		},
+		{
+			desc:    "unexpected time",
+			give:    []string{"-n"},
+			into:    time.Time{},
+			wantErr: "expected time.Time, got null",
+		},
+		{
+			desc: "bad time layout",
+			give: []string{"[", "--when", "2006-01-02T15:04:05Z", "]"},
+			into: struct {
+				When time.Time `shon:",layout=2006-01-02"`
+			}{},
+			wantErr: "bad time.Time: parsing time",
+		},
+		{
+			desc: "bad tag",
+			give: []string{"[--]"},
+			into: struct {
+				When time.Time `shon:",format=2006-01-02"`
+			}{},
+			wantErr: `field When: unknown tag option "format=2006-01-02"`,
+		},
+		{
+			desc: "bad default",
+			give: []string{"[--]"},
+			into: struct {
+				Timeout time.Duration `default:"5s seconds"`
+			}{},
+			wantErr: `field Timeout: bad default: argument 1: unexpected arguments: ["seconds"]`,
+		},
+		{
+			desc: "args not a slice",
+			give: []string{"[--]"},
+			into: struct {
+				Rest string `shon:",args"`
+			}{},
+			wantErr: "field Rest: args requires a slice, got string",
+		},
+		{
+			desc: "multiple args",
+			give: []string{"[--]"},
+			into: struct {
+				A []string `shon:",args"`
+				B []string `shon:",args"`
+			}{},
+			wantErr: "fields A and B: only one field may receive remaining positional arguments",
+		},
 		{
 			desc:    "unexpected field",
 			give:    []string{"[", "--foo", "42", "]"},
@@ -68,7 +68,7 @@ func shon2json(req request) (string, error) {
 
 	var result any
 	if err := parser(args, &result); err != nil {
-		return "", fmt.Errorf("parse SHON: %w", err)
+		return "", fmt.Errorf("parse SHON: %v", shon.FormatError(args, err))
 	}
 
 	bs, err := json.MarshalIndent(result, "", "  ")
@@ -0,0 +1,390 @@
+package shon
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ManPage generates a reference manual page in roff format
+// for a program named prog that accepts arguments for v
+// with [ParseObject] or [ParseCommand].
+// v must be a struct or a pointer to one.
+//
+// The page lists positional arguments, options, and subcommands
+// like [Usage] does, along with the default values of options,
+// whether they're required, and their help:".." struct tags.
+// Options that take arrays or objects come with an example
+// of their SHON syntax, e.g.:
+//
+//	--servers [ [ --host host --port 80 ] ]
+//
+// Examples use the default values of fields where available.
+func ManPage(prog string, v any) (string, error) {
+	ref, err := newReference(prog, v)
+	if err != nil {
+		return "", err
+	}
+
+	var w manWriter
+	w.page(ref)
+	return w.String(), nil
+}
+
+// Markdown generates a reference page in Markdown format
+// with the same contents as [ManPage].
+func Markdown(prog string, v any) (string, error) {
+	ref, err := newReference(prog, v)
+	if err != nil {
+		return "", err
+	}
+
+	var w markdownWriter
+	w.page(ref)
+	return w.String(), nil
+}
+
+// reference is the contents of a reference page,
+// independent of its format.
+type reference struct {
+	prog     string
+	synopsis string
+	args     []refEntry
+	opts     []refEntry
+	cmds     []refEntry
+}
+
+type refEntry struct {
+	synopsis string // e.g. "--verbose -t|-f"
+	note     string // e.g. "required" or "default: 5s"
+	help     string
+	example  string // SHON syntax for composite values
+
+	// Fields of a nested struct.
+	fields []refEntry
+}
+
+func newReference(prog string, v any) (*reference, error) {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("expected a struct, got %v", reflect.TypeOf(v))
+	}
+
+	dec, err := newDecoder(t)
+	if err != nil {
+		return nil, err
+	}
+	sd, ok := dec.(*structDecoder)
+	if !ok {
+		// e.g. time.Time, or types implementing encoding.TextUnmarshaler
+		return nil, fmt.Errorf("expected a struct, got %v", reflect.TypeOf(v))
+	}
+
+	infos, err := visibleFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := reference{prog: prog}
+	synopsis := []string{prog}
+	if slices.ContainsFunc(sd.fields, func(f structField) bool { return !f.positional }) {
+		synopsis = append(synopsis, "[options]")
+	}
+	for _, i := range sd.args {
+		f := sd.fields[i]
+		arg := "<" + f.names[0] + ">"
+		ref.args = append(ref.args, refEntry{synopsis: arg, note: refNote(f), help: f.help})
+		if !f.required {
+			arg = "[" + arg + "]"
+		}
+		synopsis = append(synopsis, arg)
+	}
+	if sd.argsRest >= 0 {
+		f := sd.fields[sd.argsRest]
+		arg := "<" + f.names[0] + ">..."
+		ref.args = append(ref.args, refEntry{synopsis: arg, note: refNote(f), help: f.help})
+		synopsis = append(synopsis, "["+arg+"]")
+	}
+	ref.opts = refFields(sd)
+
+	for _, info := range infos {
+		if info.tag.cmd {
+			ref.cmds = append(ref.cmds, refEntry{synopsis: info.names[0], help: info.tag.help})
+		}
+	}
+	if len(ref.cmds) > 0 {
+		synopsis = append(synopsis, "<command>", "[args...]")
+	}
+	ref.synopsis = strings.Join(synopsis, " ")
+
+	return &ref, nil
+}
+
+// refFields builds entries for the fields of sd
+// that are addressed by name.
+func refFields(sd *structDecoder) []refEntry {
+	var entries []refEntry
+	for _, f := range sd.fields {
+		if f.positional {
+			continue
+		}
+
+		key := "--" + f.names[0]
+		e := refEntry{
+			synopsis: key + " " + usageShape(f.p),
+			note:     refNote(f),
+			help:     f.help,
+		}
+		if refComposite(f.p) {
+			e.example = key + " " + strings.Join(refExample(f), " ")
+		}
+		if nested, ok := nestedStruct(f.p); ok {
+			e.fields = refFields(nested)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func refNote(f structField) string {
+	switch {
+	case f.required:
+		return "required"
+	case f.dflt != nil:
+		dflt := make([]string, len(f.dflt))
+		for i, arg := range f.dflt {
+			dflt[i] = shellQuote(arg)
+		}
+		return "default: " + strings.Join(dflt, " ")
+	default:
+		return ""
+	}
+}
+
+// refComposite reports whether values decoded by dec
+// are arrays or objects.
+func refComposite(dec decoder) bool {
+	switch completeElem(dec).(type) {
+	case *sliceDecoder, *arrayDecoder, *mapDecoder, *structDecoder:
+		return true
+	default:
+		return false
+	}
+}
+
+// refExample returns example arguments for the value of field f.
+func refExample(f structField) []string {
+	if f.dflt != nil {
+		args := make([]string, len(f.dflt))
+		for i, arg := range f.dflt {
+			args[i] = shellQuote(arg)
+		}
+		return args
+	}
+	return refExampleValue(f.p, f.names[0])
+}
+
+// refExampleValue returns example arguments for a value decoded by dec.
+// name is used as a placeholder for strings.
+func refExampleValue(dec decoder, name string) []string {
+	switch d := dec.(type) {
+	case *boolDecoder:
+		return []string{"-t"}
+	case *intDecoder, *uintDecoder:
+		return []string{"1"}
+	case *floatDecoder:
+		return []string{"1.5"}
+	case *complexDecoder:
+		return []string{"1+2i"}
+	case *stringDecoder, *anyDecoder:
+		return []string{shellQuote(name)}
+	case *durationDecoder:
+		return []string{"5s"}
+	case *timeDecoder:
+		return []string{shellQuote(time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC).Format(d.layout))}
+	case *enumDecoder:
+		return []string{shellQuote(d.values[0])}
+	case *ptrDecoder:
+		return refExampleValue(d.e, name)
+	case *sliceDecoder:
+		return refExampleArray(refExampleValue(d.e, name))
+	case *arrayDecoder:
+		return refExampleArray(refExampleValue(d.e, name))
+	case *mapDecoder:
+		key := "key"
+		if d.t.Key().Kind() != reflect.String {
+			key = strings.Join(refExampleValue(d.k, key), " ")
+		}
+		args := []string{"[", "--" + key}
+		args = append(args, refExampleValue(d.v, key)...)
+		return append(args, "]")
+	case *structDecoder:
+		args := []string{"["}
+		for _, f := range d.fields {
+			if !f.positional {
+				args = append(args, "--"+f.names[0])
+				args = append(args, refExample(f)...)
+			}
+		}
+		if len(args) == 1 {
+			return []string{"[--]"}
+		}
+		return append(args, "]")
+	default:
+		return []string{usageShape(dec)}
+	}
+}
+
+func refExampleArray(item []string) []string {
+	args := append([]string{"["}, item...)
+	return append(args, "]")
+}
+
+type manWriter struct {
+	strings.Builder
+}
+
+func (w *manWriter) page(ref *reference) {
+	fmt.Fprintf(w, ".TH %v 1\n", manEscape(strings.ToUpper(ref.prog)))
+	w.section("NAME")
+	w.line(manEscape(ref.prog))
+	w.section("SYNOPSIS")
+	w.line(manEscape(ref.synopsis))
+
+	if len(ref.args) > 0 {
+		w.section("ARGUMENTS")
+		w.entries(ref.args)
+	}
+	if len(ref.opts) > 0 {
+		w.section("OPTIONS")
+		w.entries(ref.opts)
+	}
+	if len(ref.cmds) > 0 {
+		w.section("COMMANDS")
+		w.entries(ref.cmds)
+	}
+}
+
+func (w *manWriter) section(name string) {
+	fmt.Fprintf(w, ".SH %v\n", name)
+}
+
+func (w *manWriter) entries(entries []refEntry) {
+	for _, e := range entries {
+		w.WriteString(".TP\n")
+		synopsis := `\fB` + manEscape(e.synopsis) + `\fR`
+		if len(e.note) > 0 {
+			synopsis += " (" + manEscape(e.note) + ")"
+		}
+		w.line(synopsis)
+		if len(e.help) > 0 {
+			for _, line := range strings.Split(e.help, "\n") {
+				w.line(manEscape(line))
+			}
+		}
+		if len(e.example) > 0 {
+			w.WriteString(".RS\n.PP\nExample:\n.PP\n.nf\n.RS\n")
+			w.line(manEscape(e.example))
+			w.WriteString(".RE\n.fi\n.RE\n")
+		}
+		if len(e.fields) > 0 {
+			w.WriteString(".RS\n")
+			w.entries(e.fields)
+			w.WriteString(".RE\n")
+		}
+	}
+}
+
+// line writes a line of text that was escaped with manEscape.
+func (w *manWriter) line(s string) {
+	if strings.HasPrefix(s, ".") || strings.HasPrefix(s, "'") {
+		// Don't let the text be read as a request.
+		w.WriteString(`\&`)
+	}
+	w.WriteString(s)
+	w.WriteString("\n")
+}
+
+var _manEscaper = strings.NewReplacer(`\`, `\e`, "-", `\-`)
+
+// manEscape escapes text for roff.
+func manEscape(s string) string {
+	return _manEscaper.Replace(s)
+}
+
+type markdownWriter struct {
+	strings.Builder
+}
+
+func (w *markdownWriter) page(ref *reference) {
+	fmt.Fprintf(w, "# %v\n", ref.prog)
+	w.section("Synopsis")
+	w.code(0, ref.synopsis)
+
+	if len(ref.args) > 0 {
+		w.section("Arguments")
+		w.entries(0, ref.args)
+	}
+	if len(ref.opts) > 0 {
+		w.section("Options")
+		w.entries(0, ref.opts)
+	}
+	if len(ref.cmds) > 0 {
+		w.section("Commands")
+		w.entries(0, ref.cmds)
+	}
+}
+
+func (w *markdownWriter) section(name string) {
+	fmt.Fprintf(w, "\n## %v\n\n", name)
+}
+
+// entries writes a list of entries indented by depth list levels.
+func (w *markdownWriter) entries(depth int, entries []refEntry) {
+	for i, e := range entries {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+
+		synopsis := "`" + e.synopsis + "`"
+		if len(e.note) > 0 {
+			synopsis += " (" + strings.ReplaceAll(e.note, "`", "\\`") + ")"
+		}
+		w.line(depth, "- "+synopsis)
+		if len(e.help) > 0 {
+			w.WriteString("\n")
+			for _, line := range strings.Split(e.help, "\n") {
+				w.line(depth+1, line)
+			}
+		}
+		if len(e.example) > 0 {
+			w.WriteString("\n")
+			w.line(depth+1, "Example:")
+			w.WriteString("\n")
+			w.code(depth+1, e.example)
+		}
+		if len(e.fields) > 0 {
+			w.WriteString("\n")
+			w.entries(depth+1, e.fields)
+		}
+	}
+}
+
+func (w *markdownWriter) code(depth int, s string) {
+	w.line(depth, "```")
+	w.line(depth, s)
+	w.line(depth, "```")
+}
+
+func (w *markdownWriter) line(depth int, s string) {
+	if len(s) > 0 {
+		w.WriteString(strings.Repeat("  ", depth))
+		w.WriteString(s)
+	}
+	w.WriteString("\n")
+}
@@ -0,0 +1,134 @@
+package shon
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testRefServer struct {
+	Host string `shon:"host,required" help:"Host name or IP address."`
+	Port uint16 `shon:"port" default:"80"`
+}
+
+type testRefOptions struct {
+	Verbose bool              `shon:"verbose" help:"Print more output."`
+	Name    string            `shon:"name" default:"'John Doe'"`
+	Level   string            `shon:"level" enum:"debug info"`
+	Timeout time.Duration     `shon:"timeout" default:"5s" help:"How long to wait.\n.5s or more is recommended."`
+	Since   time.Time         `shon:"since,layout=2006-01-02"`
+	Server  *testRefServer    `shon:"server" help:"Server to connect to."`
+	Mirrors []testRefServer   `shon:"mirrors"`
+	Labels  map[string]string `shon:"labels"`
+	Ports   map[int][]float64 `shon:"ports"`
+	Dest    string            `shon:"dest,arg,required" help:"Destination directory."`
+	Sources []string          `shon:"sources,args"`
+}
+
+func TestManPage(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		give any
+	}{
+		{"options", &testRefOptions{}},
+		{"commands", testRootCmd{}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			t.Run("man", func(t *testing.T) {
+				t.Parallel()
+
+				got, err := ManPage("my-tool", tt.give)
+				require.NoError(t, err)
+				assertGolden(t, filepath.Join("testdata", "reference", tt.name+".1"), got)
+			})
+
+			t.Run("markdown", func(t *testing.T) {
+				t.Parallel()
+
+				got, err := Markdown("my-tool", tt.give)
+				require.NoError(t, err)
+				assertGolden(t, filepath.Join("testdata", "reference", tt.name+".md"), got)
+			})
+		})
+	}
+}
+
+func TestManPage_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    any
+		wantErr string
+	}{
+		{"nil", nil, "expected a struct, got <nil>"},
+		{"map", map[string]any{}, "expected a struct, got map[string]interface {}"},
+		{"time", time.Time{}, "expected a struct, got time.Time"},
+		{"text unmarshaler", &netip.Addr{}, "expected a struct, got *netip.Addr"},
+		{
+			"unsupported",
+			struct {
+				C chan int
+			}{},
+			"unsupported type chan int",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := ManPage("prog", tt.give)
+			assert.ErrorContains(t, err, tt.wantErr)
+
+			_, err = Markdown("prog", tt.give)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestManPage_leafStructFields(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		At   time.Time  `shon:"at"`
+		Addr netip.Addr `shon:"addr"`
+	}
+
+	got, err := ManPage("prog", options{})
+	require.NoError(t, err)
+	assert.Contains(t, got, `\fB\-\-at <time>\fR`)
+	assert.Contains(t, got, `\fB\-\-addr <addr>\fR`)
+
+	got, err = Markdown("prog", options{})
+	require.NoError(t, err)
+	assert.Contains(t, got, "- `--at <time>`")
+	assert.Contains(t, got, "- `--addr <addr>`")
+}
+
+// assertGolden compares got against the contents of the file at path,
+// or updates the file if the -update flag is set.
+func assertGolden(t *testing.T, path, got string) {
+	t.Helper()
+
+	if *_update {
+		require.NoError(t, os.WriteFile(path, []byte(got), 0o644))
+	}
+
+	want, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, string(want), got)
+}
@@ -0,0 +1,539 @@
+// Package schema validates untyped SHON values against a JSON Schema.
+//
+// It supports the following subset of JSON Schema:
+//
+//   - type
+//   - properties, required, additionalProperties
+//   - items, minItems, maxItems
+//   - enum
+//   - minimum, maximum, exclusiveMinimum, exclusiveMaximum
+//   - minLength, maxLength, pattern
+//
+// Annotations like title, description, default, and format
+// are accepted and ignored.
+// [Compile] reports an error for all other keywords.
+//
+// Use it with [shon.ParseTree] to reject malformed input
+// before decoding it into a value of type any:
+//
+//	s, err := schema.Compile(schemaJSON)
+//	...
+//	tree, err := shon.ParseObjectTree(args)
+//	...
+//	if err := s.Validate(tree); err != nil {
+//		log.Fatal(shon.FormatError(args, err))
+//	}
+//	var v any
+//	err = shon.ParseObject(args, &v)
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"go.abhg.dev/shon"
+)
+
+// Schema is a compiled JSON Schema.
+type Schema struct {
+	// Set for the boolean schema 'false' which accepts nothing.
+	reject bool
+
+	types []string // JSON type names
+
+	properties map[string]*Schema
+	required   []string
+	additional *Schema // nil if any key is allowed
+
+	items              *Schema
+	minItems, maxItems int // -1 if unset
+
+	enum []any // values decoded with json.Number
+
+	minimum, maximum                   *big.Rat
+	exclusiveMinimum, exclusiveMaximum *big.Rat
+
+	minLength, maxLength int // -1 if unset
+	pattern              *regexp.Regexp
+}
+
+// Compile parses a JSON Schema document.
+//
+// It returns an error if the document is not valid JSON,
+// or if it uses keywords that are not supported.
+func Compile(data []byte) (*Schema, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var doc any
+	if err := dec.Decode(&doc); err != nil {
+		return nil, fmt.Errorf("bad schema: %w", err)
+	}
+	return compile("", doc)
+}
+
+var _annotations = []string{
+	"$schema", "$id", "$comment",
+	"title", "description", "default", "examples", "format",
+	"deprecated", "readOnly", "writeOnly",
+}
+
+var _typeNames = []string{
+	"null", "boolean", "string", "number", "integer", "array", "object",
+}
+
+// compile compiles the schema doc found at the given JSON pointer.
+func compile(ptr string, doc any) (*Schema, error) {
+	s := Schema{minItems: -1, maxItems: -1, minLength: -1, maxLength: -1}
+	if b, ok := doc.(bool); ok {
+		s.reject = !b
+		return &s, nil
+	}
+
+	obj, ok := doc.(map[string]any)
+	if !ok {
+		return nil, schemaErrorf(ptr, "expected an object or a boolean")
+	}
+
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+
+	var err error
+	for _, key := range keys {
+		val, kptr := obj[key], ptr+"/"+key
+		switch key {
+		case "type":
+			s.types, err = compileTypes(kptr, val)
+
+		case "properties":
+			props, ok := val.(map[string]any)
+			if !ok {
+				return nil, schemaErrorf(kptr, "expected an object")
+			}
+			s.properties = make(map[string]*Schema, len(props))
+			for name, prop := range props {
+				if s.properties[name], err = compile(kptr+"/"+name, prop); err != nil {
+					return nil, err
+				}
+			}
+
+		case "required":
+			s.required, err = compileStrings(kptr, val)
+
+		case "additionalProperties":
+			s.additional, err = compile(kptr, val)
+
+		case "items":
+			s.items, err = compile(kptr, val)
+
+		case "minItems":
+			s.minItems, err = compileLength(kptr, val)
+
+		case "maxItems":
+			s.maxItems, err = compileLength(kptr, val)
+
+		case "enum":
+			values, ok := val.([]any)
+			if !ok {
+				return nil, schemaErrorf(kptr, "expected an array")
+			}
+			s.enum = values
+
+		case "minimum":
+			s.minimum, err = compileNumber(kptr, val)
+
+		case "maximum":
+			s.maximum, err = compileNumber(kptr, val)
+
+		case "exclusiveMinimum":
+			s.exclusiveMinimum, err = compileNumber(kptr, val)
+
+		case "exclusiveMaximum":
+			s.exclusiveMaximum, err = compileNumber(kptr, val)
+
+		case "minLength":
+			s.minLength, err = compileLength(kptr, val)
+
+		case "maxLength":
+			s.maxLength, err = compileLength(kptr, val)
+
+		case "pattern":
+			pattern, ok := val.(string)
+			if !ok {
+				return nil, schemaErrorf(kptr, "expected a string")
+			}
+			if s.pattern, err = regexp.Compile(pattern); err != nil {
+				return nil, schemaErrorf(kptr, "bad pattern: %v", err)
+			}
+
+		default:
+			if !slices.Contains(_annotations, key) {
+				return nil, schemaErrorf(kptr, "unsupported keyword")
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &s, nil
+}
+
+func compileTypes(ptr string, val any) ([]string, error) {
+	var types []string
+	switch val := val.(type) {
+	case string:
+		types = []string{val}
+	case []any:
+		var err error
+		if types, err = compileStrings(ptr, val); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, schemaErrorf(ptr, "expected a string or an array of strings")
+	}
+
+	for _, t := range types {
+		if !slices.Contains(_typeNames, t) {
+			return nil, schemaErrorf(ptr, "unknown type %q", t)
+		}
+	}
+	return types, nil
+}
+
+func compileStrings(ptr string, val any) ([]string, error) {
+	items, ok := val.([]any)
+	if !ok {
+		return nil, schemaErrorf(ptr, "expected an array of strings")
+	}
+
+	strs := make([]string, len(items))
+	for i, item := range items {
+		if strs[i], ok = item.(string); !ok {
+			return nil, schemaErrorf(ptr, "expected an array of strings")
+		}
+	}
+	return strs, nil
+}
+
+func compileNumber(ptr string, val any) (*big.Rat, error) {
+	if num, ok := val.(json.Number); ok {
+		if r, ok := new(big.Rat).SetString(num.String()); ok {
+			return r, nil
+		}
+	}
+	return nil, schemaErrorf(ptr, "expected a number")
+}
+
+func compileLength(ptr string, val any) (int, error) {
+	if num, ok := val.(json.Number); ok {
+		if n, err := strconv.Atoi(num.String()); err == nil && n >= 0 {
+			return n, nil
+		}
+	}
+	return 0, schemaErrorf(ptr, "expected a non-negative integer")
+}
+
+func schemaErrorf(ptr, format string, args ...any) error {
+	if len(ptr) == 0 {
+		ptr = "/"
+	}
+	return fmt.Errorf("schema %v: %v", ptr, fmt.Sprintf(format, args...))
+}
+
+// Validate reports whether the value rooted at n satisfies the schema.
+//
+// Each violation is reported as a [*shon.DecodeError]
+// pointing to the offending argument,
+// so the result may be rendered with [shon.FormatError].
+// Validate reports all violations it finds, joined with [errors.Join].
+//
+// SHON does not distinguish between strings and numbers
+// unless strings are escaped with '--',
+// so scalars that look like numbers satisfy both "string" and "number".
+func (s *Schema) Validate(n *shon.Node) error {
+	var v validator
+	v.validate(s, n, "")
+	return errors.Join(v.errs...)
+}
+
+type validator struct {
+	errs []error
+}
+
+func (v *validator) errorf(n *shon.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, &shon.DecodeError{
+		Index: n.Start,
+		Token: nodeToken(n),
+		Path:  path,
+		Kind:  n.Kind,
+		Err:   fmt.Errorf(format, args...),
+	})
+}
+
+func (v *validator) validate(s *Schema, n *shon.Node, path string) {
+	if s.reject {
+		v.errorf(n, path, "value not allowed")
+		return
+	}
+
+	if len(s.types) > 0 && !slices.ContainsFunc(s.types, func(t string) bool { return hasType(n, t) }) {
+		v.errorf(n, path, "expected %v, got %v", strings.Join(s.types, " or "), nodeType(n))
+		return
+	}
+
+	if s.enum != nil && !slices.ContainsFunc(s.enum, func(want any) bool { return equal(n, want) }) {
+		v.errorf(n, path, "unexpected value: must be one of %v", formatValues(s.enum))
+	}
+
+	switch n.Kind {
+	case shon.StringKind, shon.ScalarKind:
+		v.validateScalar(s, n, path)
+	case shon.ArrayKind:
+		v.validateArray(s, n, path)
+	case shon.ObjectKind:
+		v.validateObject(s, n, path)
+	}
+}
+
+func (v *validator) validateScalar(s *Schema, n *shon.Node, path string) {
+	if num, ok := nodeNumber(n); ok {
+		switch {
+		case s.minimum != nil && num.Cmp(s.minimum) < 0:
+			v.errorf(n, path, "%v is less than minimum %v", n.Text, s.minimum.RatString())
+		case s.exclusiveMinimum != nil && num.Cmp(s.exclusiveMinimum) <= 0:
+			v.errorf(n, path, "%v must be greater than %v", n.Text, s.exclusiveMinimum.RatString())
+		}
+		switch {
+		case s.maximum != nil && num.Cmp(s.maximum) > 0:
+			v.errorf(n, path, "%v is greater than maximum %v", n.Text, s.maximum.RatString())
+		case s.exclusiveMaximum != nil && num.Cmp(s.exclusiveMaximum) >= 0:
+			v.errorf(n, path, "%v must be less than %v", n.Text, s.exclusiveMaximum.RatString())
+		}
+	}
+
+	length := utf8.RuneCountInString(n.Text)
+	if s.minLength >= 0 && length < s.minLength {
+		v.errorf(n, path, "length %d is less than minLength %d", length, s.minLength)
+	}
+	if s.maxLength >= 0 && length > s.maxLength {
+		v.errorf(n, path, "length %d is greater than maxLength %d", length, s.maxLength)
+	}
+	if s.pattern != nil && !s.pattern.MatchString(n.Text) {
+		v.errorf(n, path, "%q does not match pattern %q", n.Text, s.pattern)
+	}
+}
+
+func (v *validator) validateArray(s *Schema, n *shon.Node, path string) {
+	if s.minItems >= 0 && len(n.Children) < s.minItems {
+		v.errorf(n, path, "%d items is less than minItems %d", len(n.Children), s.minItems)
+	}
+	if s.maxItems >= 0 && len(n.Children) > s.maxItems {
+		v.errorf(n, path, "%d items is greater than maxItems %d", len(n.Children), s.maxItems)
+	}
+	if s.items != nil {
+		for i, item := range n.Children {
+			v.validate(s.items, item, path+"["+strconv.Itoa(i)+"]")
+		}
+	}
+}
+
+func (v *validator) validateObject(s *Schema, n *shon.Node, path string) {
+	var missing []string
+	for _, name := range s.required {
+		if !slices.Contains(n.Keys, name) {
+			missing = append(missing, path+"."+name)
+		}
+	}
+	if len(missing) > 0 {
+		v.errs = append(v.errs, &shon.DecodeError{
+			Index: n.Start,
+			Token: nodeToken(n),
+			Path:  path,
+			Kind:  n.Kind,
+			Err:   &shon.MissingFieldsError{Paths: missing},
+		})
+	}
+
+	for i, key := range n.Keys {
+		child, cpath := n.Children[i], path+"."+key
+		if prop, ok := s.properties[key]; ok {
+			v.validate(prop, child, cpath)
+			continue
+		}
+
+		switch {
+		case s.additional == nil:
+			// anything goes
+		case s.additional.reject:
+			v.errs = append(v.errs, &shon.DecodeError{
+				Index: child.KeyIndex,
+				Token: "--" + key,
+				Path:  cpath,
+				Kind:  child.Kind,
+				Err:   fmt.Errorf("unknown property %q", key),
+			})
+		default:
+			v.validate(s.additional, child, cpath)
+		}
+	}
+}
+
+// hasType reports whether n is a value of the given JSON type.
+func hasType(n *shon.Node, typ string) bool {
+	switch typ {
+	case "null":
+		return n.Kind == shon.NullKind
+	case "boolean":
+		return n.Kind == shon.BoolKind
+	case "string":
+		return n.Kind == shon.StringKind || n.Kind == shon.ScalarKind
+	case "number":
+		_, ok := nodeNumber(n)
+		return ok
+	case "integer":
+		num, ok := nodeNumber(n)
+		return ok && num.IsInt()
+	case "array":
+		return n.Kind == shon.ArrayKind
+	case "object":
+		return n.Kind == shon.ObjectKind
+	default:
+		return false
+	}
+}
+
+// nodeType names the JSON type of n for error messages.
+func nodeType(n *shon.Node) string {
+	switch n.Kind {
+	case shon.NullKind:
+		return "null"
+	case shon.BoolKind:
+		return "boolean"
+	case shon.ScalarKind:
+		if _, ok := nodeNumber(n); ok {
+			return "number"
+		}
+		return "string"
+	case shon.StringKind:
+		return "string"
+	case shon.ArrayKind:
+		return "array"
+	case shon.ObjectKind:
+		return "object"
+	default:
+		return n.Kind.String()
+	}
+}
+
+// nodeNumber returns the value of n if it is a scalar number.
+// Strings escaped with '--' are never numbers.
+func nodeNumber(n *shon.Node) (*big.Rat, bool) {
+	if n.Kind != shon.ScalarKind || !n.Number {
+		return nil, false
+	}
+	return new(big.Rat).SetString(n.Text)
+}
+
+// nodeToken returns the argument that n starts at.
+func nodeToken(n *shon.Node) string {
+	switch n.Kind {
+	case shon.NullKind:
+		return "-n"
+	case shon.BoolKind:
+		if n.Bool {
+			return "-t"
+		}
+		return "-f"
+	case shon.StringKind:
+		if n.Escaped {
+			return "--"
+		}
+		return n.Text
+	case shon.ScalarKind:
+		return n.Text
+	case shon.ArrayKind:
+		if n.End-n.Start == 1 {
+			return "[]"
+		}
+		return "["
+	case shon.ObjectKind:
+		if n.End-n.Start == 1 {
+			return "[--]"
+		}
+		return "["
+	default:
+		return ""
+	}
+}
+
+// equal reports whether n holds the JSON value want,
+// which was decoded with json.Number.
+func equal(n *shon.Node, want any) bool {
+	switch want := want.(type) {
+	case nil:
+		return n.Kind == shon.NullKind
+	case bool:
+		return n.Kind == shon.BoolKind && n.Bool == want
+	case string:
+		return (n.Kind == shon.StringKind || n.Kind == shon.ScalarKind) && n.Text == want
+	case json.Number:
+		num, ok := nodeNumber(n)
+		if !ok {
+			return false
+		}
+		wantNum, ok := new(big.Rat).SetString(want.String())
+		return ok && num.Cmp(wantNum) == 0
+	case []any:
+		if n.Kind != shon.ArrayKind || len(n.Children) != len(want) {
+			return false
+		}
+		for i, item := range want {
+			if !equal(n.Children[i], item) {
+				return false
+			}
+		}
+		return true
+	case map[string]any:
+		if n.Kind != shon.ObjectKind || len(n.Keys) != len(want) {
+			return false
+		}
+		for i, key := range n.Keys {
+			item, ok := want[key]
+			if !ok || !equal(n.Children[i], item) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// formatValues renders JSON values for error messages.
+func formatValues(values []any) string {
+	strs := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := v.(string); ok {
+			strs[i] = s
+			continue
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			b = []byte(fmt.Sprint(v))
+		}
+		strs[i] = string(b)
+	}
+	return strings.Join(strs, ", ")
+}
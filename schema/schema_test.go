@@ -0,0 +1,298 @@
+package schema
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.abhg.dev/shon"
+)
+
+const _serverSchema = `{
+	"$schema": "https://json-schema.org/draft/2020-12/schema",
+	"type": "object",
+	"properties": {
+		"name": {"type": "string", "minLength": 2, "maxLength": 8, "pattern": "^[a-z]+$"},
+		"port": {"type": "integer", "minimum": 1, "maximum": 65535},
+		"ratio": {"type": "number", "exclusiveMinimum": 0, "exclusiveMaximum": 1},
+		"level": {"enum": ["debug", "info", 3, null]},
+		"verbose": {"type": "boolean", "description": "Print more output."},
+		"tags": {"type": "array", "items": {"type": "string"}, "minItems": 1, "maxItems": 2},
+		"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+		"parent": {"type": ["object", "null"]}
+	},
+	"required": ["name", "port"],
+	"additionalProperties": false
+}`
+
+func TestValidate(t *testing.T) {
+	t.Parallel()
+
+	s, err := Compile([]byte(_serverSchema))
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		give    []string
+		wantErr string
+	}{
+		{
+			desc: "valid",
+			give: []string{
+				"--name", "web", "--port", "80", "--ratio", "0.5",
+				"--level", "3", "--verbose", "-t",
+				"--tags", "[", "a", "--", "1", "]",
+				"--labels", "[", "--x", "y", "]",
+				"--parent", "-n",
+			},
+		},
+		{
+			desc: "numeric string",
+			give: []string{"--name", "ab", "--port", "80", "--labels", "[", "--x", "42", "]"},
+		},
+		{
+			desc: "null enum",
+			give: []string{"--name", "ab", "--port", "80", "--level", "-n"},
+		},
+		{
+			desc:    "missing required",
+			give:    []string{"--verbose", "-t"},
+			wantErr: "argument 0: missing required fields: .name, .port",
+		},
+		{
+			desc:    "wrong type",
+			give:    []string{"--name", "ab", "--port", "http"},
+			wantErr: "argument 3 (.port): expected integer, got string",
+		},
+		{
+			desc:    "not an integer",
+			give:    []string{"--name", "ab", "--port", "8.5"},
+			wantErr: "argument 3 (.port): expected integer, got number",
+		},
+		{
+			desc:    "escaped number",
+			give:    []string{"--name", "ab", "--port", "--", "80"},
+			wantErr: "argument 3 (.port): expected integer, got string",
+		},
+		{
+			desc:    "minimum",
+			give:    []string{"--name", "ab", "--port", "0"},
+			wantErr: "argument 3 (.port): 0 is less than minimum 1",
+		},
+		{
+			desc:    "maximum",
+			give:    []string{"--name", "ab", "--port", "65536"},
+			wantErr: "argument 3 (.port): 65536 is greater than maximum 65535",
+		},
+		{
+			desc:    "exclusive bounds",
+			give:    []string{"--name", "ab", "--port", "1", "--ratio", "1"},
+			wantErr: "argument 5 (.ratio): 1 must be less than 1",
+		},
+		{
+			desc:    "pattern",
+			give:    []string{"--name", "Web", "--port", "1"},
+			wantErr: `argument 1 (.name): "Web" does not match pattern "^[a-z]+$"`,
+		},
+		{
+			desc:    "length",
+			give:    []string{"--name=abcdefghi", "--port", "1"},
+			wantErr: "argument 0 (.name): length 9 is greater than maxLength 8",
+		},
+		{
+			desc:    "enum",
+			give:    []string{"--name", "ab", "--port", "1", "--level", "warn"},
+			wantErr: "argument 5 (.level): unexpected value: must be one of debug, info, 3, null",
+		},
+		{
+			desc:    "items",
+			give:    []string{"--name", "ab", "--port", "1", "--tags", "[", "a", "-t", "]"},
+			wantErr: "argument 7 (.tags[1]): expected string, got boolean",
+		},
+		{
+			desc:    "max items",
+			give:    []string{"--name", "ab", "--port", "1", "--tags", "[", "a", "b", "c", "]"},
+			wantErr: "argument 5 (.tags): 3 items is greater than maxItems 2",
+		},
+		{
+			desc:    "min items",
+			give:    []string{"--name", "ab", "--port", "1", "--tags", "[]"},
+			wantErr: "argument 5 (.tags): 0 items is less than minItems 1",
+		},
+		{
+			desc:    "additional properties schema",
+			give:    []string{"--name", "ab", "--port", "1", "--labels", "[", "--x", "[]", "]"},
+			wantErr: "argument 7 (.labels.x): expected string, got array",
+		},
+		{
+			desc:    "unknown property",
+			give:    []string{"--name", "ab", "--port", "1", "--prot", "2"},
+			wantErr: `argument 4 (.prot): unknown property "prot"`,
+		},
+		{
+			desc: "all violations",
+			give: []string{"--name", "A", "--port", "0", "--extra", "-t"},
+			wantErr: "argument 1 (.name): length 1 is less than minLength 2\n" +
+				`argument 1 (.name): "A" does not match pattern "^[a-z]+$"` + "\n" +
+				"argument 3 (.port): 0 is less than minimum 1\n" +
+				`argument 4 (.extra): unknown property "extra"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tree, err := shon.ParseObjectTree(tt.give)
+			require.NoError(t, err)
+
+			err = s.Validate(tree)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.EqualError(t, err, tt.wantErr)
+
+			var decErr *shon.DecodeError
+			assert.ErrorAs(t, err, &decErr)
+		})
+	}
+}
+
+func TestValidate_formatError(t *testing.T) {
+	t.Parallel()
+
+	s, err := Compile([]byte(_serverSchema))
+	require.NoError(t, err)
+
+	args := []string{"--name", "ab", "--port", "http"}
+	tree, err := shon.ParseObjectTree(args)
+	require.NoError(t, err)
+
+	assert.Equal(t, "argument 3 (.port): expected integer, got string\n"+
+		"  --name ab --port http\n"+
+		"                   ^~~~",
+		shon.FormatError(args, s.Validate(tree)))
+}
+
+func TestValidate_booleanSchema(t *testing.T) {
+	t.Parallel()
+
+	tree, err := shon.ParseTree([]string{"x"})
+	require.NoError(t, err)
+
+	s, err := Compile([]byte(`true`))
+	require.NoError(t, err)
+	assert.NoError(t, s.Validate(tree))
+
+	s, err = Compile([]byte(`false`))
+	require.NoError(t, err)
+	assert.EqualError(t, s.Validate(tree), "argument 0: value not allowed")
+}
+
+func TestValidate_jsonSchema(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `shon:"host,required"`
+		Port uint16 `shon:"port" default:"80"`
+	}
+
+	type options struct {
+		Level   string   `shon:"level" enum:"debug info"`
+		Retries *int8    `shon:"retries"`
+		Servers []server `shon:"servers"`
+		Pair    [2]int   `shon:"pair"`
+	}
+
+	data, err := shon.JSONSchema(reflect.TypeOf(options{}))
+	require.NoError(t, err)
+	s, err := Compile(data)
+	require.NoError(t, err)
+
+	tests := []struct {
+		desc    string
+		give    []string
+		wantErr string
+	}{
+		{
+			desc: "valid",
+			give: []string{"--level", "info", "--retries", "-n", "--servers", "[", "[", "--host", "a", "]", "]"},
+		},
+		{
+			desc:    "out of range",
+			give:    []string{"--retries", "200"},
+			wantErr: "argument 1 (.retries): 200 is greater than maximum 127",
+		},
+		{
+			desc:    "missing nested field",
+			give:    []string{"--servers", "[", "[", "--port", "1", "]", "]"},
+			wantErr: "argument 2 (.servers[0]): missing required field .servers[0].host",
+		},
+		{
+			desc:    "array length",
+			give:    []string{"--pair", "[", "1", "2", "3", "]"},
+			wantErr: "argument 1 (.pair): 3 items is greater than maxItems 2",
+		},
+		{
+			desc:    "enum",
+			give:    []string{"--level", "warn"},
+			wantErr: "argument 1 (.level): unexpected value: must be one of debug, info",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			tree, err := shon.ParseObjectTree(tt.give)
+			require.NoError(t, err)
+
+			err = s.Validate(tree)
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCompile_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    string
+		wantErr string
+	}{
+		{"not json", `{`, "bad schema: unexpected EOF"},
+		{"not a schema", `42`, "schema /: expected an object or a boolean"},
+		{"unsupported keyword", `{"anyOf": []}`, "schema /anyOf: unsupported keyword"},
+		{"unknown type", `{"type": "int"}`, `schema /type: unknown type "int"`},
+		{"bad type", `{"type": 1}`, "schema /type: expected a string or an array of strings"},
+		{"bad required", `{"required": [1]}`, "schema /required: expected an array of strings"},
+		{"bad minimum", `{"minimum": "1"}`, "schema /minimum: expected a number"},
+		{"bad maxLength", `{"maxLength": -1}`, "schema /maxLength: expected a non-negative integer"},
+		{"bad pattern", `{"pattern": "("}`, "schema /pattern: bad pattern: error parsing regexp: missing closing ): `(`"},
+		{"bad enum", `{"enum": {}}`, "schema /enum: expected an array"},
+		{
+			"nested",
+			`{"properties": {"a": {"items": {"type": "x"}}}}`,
+			`schema /properties/a/items/type: unknown type "x"`,
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := Compile([]byte(tt.give))
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package shon
+
+import (
+	"strconv"
+	"strings"
+)
+
+// maxSuggestions is the maximum number of suggestions
+// reported for a single error.
+const maxSuggestions = 3
+
+// suggest returns the names from candidates that are close enough to
+// name to be plausible typos.
+// If there are several, only the closest ones are returned.
+func suggest(name string, candidates []string) []string {
+	// Allow roughly one edit for every three characters.
+	best := max(1, len(name)/3)
+
+	var names []string
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(name), strings.ToLower(c))
+		switch {
+		case d < best:
+			best = d
+			names = append(names[:0], c)
+		case d == best:
+			names = append(names, c)
+		}
+	}
+
+	if len(names) > maxSuggestions {
+		names = names[:maxSuggestions]
+	}
+	return names
+}
+
+// suggestFlag returns suggestions for arg,
+// a '-'-prefixed argument that isn't one of the flags we understand.
+func suggestFlag(arg string) []string {
+	var suggestions []string
+	if word := strings.ToLower(strings.TrimLeft(arg, "-")); len(word) > 0 {
+		for _, f := range []struct {
+			flag  string
+			words []string
+		}{
+			{"-t", []string{"true", "yes"}},
+			{"-f", []string{"false", "no"}},
+			{"-n", []string{"null", "nil", "none"}},
+		} {
+			for _, w := range f.words {
+				if strings.HasPrefix(w, word) {
+					suggestions = append(suggestions, f.flag)
+					break
+				}
+			}
+		}
+	}
+
+	// The argument may have been intended as a string.
+	return append(suggestions, "-- "+arg)
+}
+
+// didYouMean renders suggestions as a suffix for an error message.
+// It returns an empty string if there are no suggestions.
+func didYouMean(suggestions []string) string {
+	var sb strings.Builder
+	for i, s := range suggestions {
+		switch {
+		case i == 0:
+			sb.WriteString("; did you mean ")
+		case len(suggestions) == 2:
+			sb.WriteString(" or ")
+		case i == len(suggestions)-1:
+			sb.WriteString(", or ")
+		default:
+			sb.WriteString(", ")
+		}
+		sb.WriteString(strconv.Quote(s))
+	}
+	if len(suggestions) > 0 {
+		sb.WriteString("?")
+	}
+	return sb.String()
+}
+
+// editDistance reports the number of single-byte insertions, deletions,
+// substitutions, and transpositions of adjacent bytes
+// needed to turn a into b.
+func editDistance(a, b string) int {
+	// prev2, prev, and cur are the last three rows of the matrix.
+	prev2 := make([]int, len(b)+1)
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(
+				prev[j]+1,      // deletion
+				cur[j-1]+1,     // insertion
+				prev[j-1]+cost, // substitution
+			)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1) // transposition
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,123 @@
+package shon
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEditDistance(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"abc", "", 3},
+		{"abc", "abc", 0},
+		{"fist-name", "first-name", 1},
+		{"hots", "host", 1},
+		{"kitten", "sitting", 3},
+		{"abcd", "badc", 2},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.a+"/"+tt.b, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, editDistance(tt.a, tt.b))
+			assert.Equal(t, tt.want, editDistance(tt.b, tt.a))
+		})
+	}
+}
+
+func TestSuggest(t *testing.T) {
+	t.Parallel()
+
+	names := []string{"first-name", "last-name", "age", "address", "page"}
+
+	tests := []struct {
+		give string
+		want []string
+	}{
+		{"fist-name", []string{"first-name"}},
+		{"First-Name", []string{"first-name"}},
+		{"lastname", []string{"last-name"}},
+		{"ag", []string{"age"}},
+		{"gae", []string{"age"}},
+		{"ages", []string{"age"}},
+		{"pages", []string{"page"}},
+		{"pge", []string{"age", "page"}},
+		{"name", nil},
+		{"xyz", nil},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, suggest(tt.give, names))
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		t.Parallel()
+
+		got := suggest("a", []string{"b", "c", "d", "e"})
+		assert.Equal(t, []string{"b", "c", "d"}, got)
+	})
+}
+
+func TestSuggestFlag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give string
+		want []string
+	}{
+		{"-true", []string{"-t", "-- -true"}},
+		{"--TRUE", []string{"-t", "-- --TRUE"}},
+		{"-false", []string{"-f", "-- -false"}},
+		{"-no", []string{"-f", "-n", "-- -no"}},
+		{"-nil", []string{"-n", "-- -nil"}},
+		{"-null", []string{"-n", "-- -null"}},
+		{"-foo", []string{"-- -foo"}},
+		{"--", []string{"-- --"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, suggestFlag(tt.give))
+		})
+	}
+}
+
+func TestDidYouMean(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give []string
+		want string
+	}{
+		{nil, ""},
+		{[]string{"a"}, `; did you mean "a"?`},
+		{[]string{"a", "b"}, `; did you mean "a" or "b"?`},
+		{[]string{"a", "b", "c"}, `; did you mean "a", "b", or "c"?`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.want, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, didYouMean(tt.give))
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// fieldTag is the parsed form of the shon:".." tag on a struct field.
+//
+// The tag takes the form:
+//
+//	shon:"name,opt1,opt2,..."
+//
+// Where name is the name of the field and may be empty,
+// and the options are one of the following:
+//
+//	required       field must be present in the object
+//	inline         promote the fields of this struct to the parent
+//	remaining      map that receives all unknown keys of the object
+//	passthrough    []string that receives all unknown keys of the object
+//	               and their values as raw arguments
+//	arg            positional argument of ParseObject
+//	args           slice that receives the remaining positional arguments
+//	cmd            subcommand for ParseCommand
+//	layout=LAYOUT  time.Time layout; must be the last option
+//
+// The default value for the field is specified in a separate tag:
+//
+//	default:"args..."
+//
+// Where args is a space-separated list of SHON arguments
+// that may be quoted like in a POSIX shell.
+//
+// The values allowed for the field may be restricted
+// with another tag in the same format:
+//
+//	enum:"args..."
+//
+// Help text for the field is specified in another tag:
+//
+//	help:"text"
+type fieldTag struct {
+	name string // empty if unset
+	skip bool   // shon:"-"
+
+	required bool // whether the field must be specified
+	inline   bool // whether to promote fields of this struct
+
+	// Whether this field receives keys
+	// that don't match any other field.
+	remaining bool
+
+	// Same as remaining,
+	// but the keys are received as raw arguments.
+	passthrough bool
+
+	// Whether this field is a positional argument,
+	// and whether it receives all remaining positional arguments.
+	arg, args bool
+
+	// Whether this field is a subcommand.
+	cmd bool
+
+	// Layout for time.Time values. Empty if unset.
+	layout string
+
+	// Arguments for the default value of the field.
+	// nil if unset.
+	dflt []string
+
+	// Values allowed for the field.
+	// nil if unset.
+	enum []string
+
+	// Help text for the field. Empty if unset.
+	help string
+}
+
+func parseFieldTag(f reflect.StructField) (fieldTag, error) {
+	var ft fieldTag
+	if dflt, ok := f.Tag.Lookup("default"); ok {
+		args, err := splitArgs(dflt)
+		if err != nil {
+			return ft, fmt.Errorf("field %v: bad default: %w", f.Name, err)
+		}
+		ft.dflt = append([]string{}, args...) // non-nil
+	}
+	if enum, ok := f.Tag.Lookup("enum"); ok {
+		args, err := splitArgs(enum)
+		if err != nil {
+			return ft, fmt.Errorf("field %v: bad enum: %w", f.Name, err)
+		}
+		if len(args) == 0 {
+			return ft, fmt.Errorf("field %v: enum must not be empty", f.Name)
+		}
+		ft.enum = args
+	}
+	ft.help = f.Tag.Get("help")
+
+	tag, ok := f.Tag.Lookup("shon")
+	if !ok {
+		return ft, nil
+	}
+	if tag == "-" {
+		return fieldTag{skip: true}, nil
+	}
+
+	name, opts, _ := strings.Cut(tag, ",")
+	ft.name = name
+	for len(opts) > 0 {
+		var opt string
+		if strings.HasPrefix(opts, "layout=") {
+			// Layouts may contain commas,
+			// so they consume the rest of the tag.
+			opt, opts = opts, ""
+		} else {
+			opt, opts, _ = strings.Cut(opts, ",")
+		}
+
+		key, value, _ := strings.Cut(opt, "=")
+		switch key {
+		case "required":
+			if len(value) > 0 {
+				return ft, fmt.Errorf("field %v: tag option %q does not take a value", f.Name, key)
+			}
+			ft.required = true
+
+		case "inline", "remaining", "passthrough", "arg", "args", "cmd":
+			if len(value) > 0 {
+				return ft, fmt.Errorf("field %v: tag option %q does not take a value", f.Name, key)
+			}
+			switch key {
+			case "inline":
+				ft.inline = true
+			case "remaining":
+				ft.remaining = true
+			case "passthrough":
+				ft.passthrough = true
+			case "arg":
+				ft.arg = true
+			case "args":
+				ft.args = true
+			case "cmd":
+				ft.cmd = true
+			}
+
+		case "layout":
+			if len(value) == 0 {
+				return ft, fmt.Errorf("field %v: layout must not be empty", f.Name)
+			}
+			ft.layout = value
+
+		default:
+			return ft, fmt.Errorf("field %v: unknown tag option %q", f.Name, opt)
+		}
+	}
+
+	if opts := ft.exclusiveOpts(); len(opts) > 1 {
+		return ft, fmt.Errorf("field %v: %v and %v cannot be used together", f.Name, opts[0], opts[1])
+	}
+	if ft.required && ft.dflt != nil {
+		return ft, fmt.Errorf("field %v: required fields cannot have a default", f.Name)
+	}
+	return ft, nil
+}
+
+// names reports the names that field f with this tag
+// should be addressed by.
+// If a name is set in the tag, this contains just one.
+// Otherwise it contains our guess at the kebab case version
+// of the field name, and the field name itself.
+//
+// The first name in the list is the canonical name for the field.
+func (t fieldTag) names(f reflect.StructField) []string {
+	if len(t.name) > 0 {
+		return []string{t.name}
+	}
+	return []string{toKebab(f.Name), f.Name}
+}
+
+// catchAll reports whether the field receives unknown keys of the object.
+func (t fieldTag) catchAll() bool {
+	return t.remaining || t.passthrough
+}
+
+// positional reports whether the field receives positional arguments.
+func (t fieldTag) positional() bool {
+	return t.arg || t.args
+}
+
+// exclusiveOpts returns the options set on the tag
+// that change how the field is addressed.
+// At most one of these may be used on a field.
+func (t fieldTag) exclusiveOpts() []string {
+	var opts []string
+	for _, o := range []struct {
+		name string
+		set  bool
+	}{
+		{"inline", t.inline},
+		{"remaining", t.remaining},
+		{"passthrough", t.passthrough},
+		{"arg", t.arg},
+		{"args", t.args},
+		{"cmd", t.cmd},
+	} {
+		if o.set {
+			opts = append(opts, o.name)
+		}
+	}
+	return opts
+}
+
+// splitArgs splits s into a list of arguments
+// separated by whitespace.
+//
+// Arguments may be quoted like in a POSIX shell:
+// with single quotes to take the contents literally,
+// with double quotes to allow escaping '"' and '\' with a backslash,
+// or with a backslash before a single character outside quotes.
+func splitArgs(s string) ([]string, error) {
+	var (
+		args  []string
+		arg   strings.Builder
+		inArg bool // whether we're inside an argument
+		quote rune // active quote character, if any
+		esc   bool // whether the previous character was a backslash
+	)
+	for _, r := range s {
+		switch {
+		case esc:
+			if quote == '"' && r != '"' && r != '\\' {
+				arg.WriteByte('\\')
+			}
+			arg.WriteRune(r)
+			esc = false
+
+		case quote == '\'':
+			if r == '\'' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+
+		case r == '\\':
+			inArg = true
+			esc = true
+
+		case quote == '"':
+			if r == '"' {
+				quote = 0
+			} else {
+				arg.WriteRune(r)
+			}
+
+		case r == '\'' || r == '"':
+			inArg = true
+			quote = r
+
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, arg.String())
+				arg.Reset()
+				inArg = false
+			}
+
+		default:
+			inArg = true
+			arg.WriteRune(r)
+		}
+	}
+
+	switch {
+	case esc:
+		return nil, errors.New("unexpected end of input after '\\'")
+	case quote != 0:
+		return nil, fmt.Errorf("unclosed %c", quote)
+	}
+
+	if inArg {
+		args = append(args, arg.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,284 @@
+package shon
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFieldTag(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc      string
+		give      reflect.StructTag
+		want      fieldTag
+		wantNames []string
+	}{
+		{
+			desc:      "none",
+			want:      fieldTag{},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "other tags",
+			give:      `json:"baz"`,
+			want:      fieldTag{},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "skip",
+			give:      `shon:"-"`,
+			want:      fieldTag{skip: true},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "name",
+			give:      `shon:"baz"`,
+			want:      fieldTag{name: "baz"},
+			wantNames: []string{"baz"},
+		},
+		{
+			desc:      "dash with options",
+			give:      `shon:"-,layout=2006"`,
+			want:      fieldTag{name: "-", layout: "2006"},
+			wantNames: []string{"-"},
+		},
+		{
+			desc:      "options without name",
+			give:      `shon:",layout=2006-01-02"`,
+			want:      fieldTag{layout: "2006-01-02"},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "required",
+			give:      `shon:"baz,required"`,
+			want:      fieldTag{name: "baz", required: true},
+			wantNames: []string{"baz"},
+		},
+		{
+			desc:      "required with layout",
+			give:      `shon:",required,layout=2006-01-02"`,
+			want:      fieldTag{required: true, layout: "2006-01-02"},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "default",
+			give:      `shon:"baz" default:"[ a b ]"`,
+			want:      fieldTag{name: "baz", dflt: []string{"[", "a", "b", "]"}},
+			wantNames: []string{"baz"},
+		},
+		{
+			desc:      "default without shon tag",
+			give:      `default:"5s"`,
+			want:      fieldTag{dflt: []string{"5s"}},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "empty default",
+			give:      `default:""`,
+			want:      fieldTag{dflt: []string{}},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "inline",
+			give:      `shon:",inline"`,
+			want:      fieldTag{inline: true},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "remaining",
+			give:      `shon:",remaining"`,
+			want:      fieldTag{remaining: true},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "passthrough",
+			give:      `shon:",passthrough"`,
+			want:      fieldTag{passthrough: true},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "arg",
+			give:      `shon:"src,arg,required"`,
+			want:      fieldTag{name: "src", arg: true, required: true},
+			wantNames: []string{"src"},
+		},
+		{
+			desc:      "args",
+			give:      `shon:",args"`,
+			want:      fieldTag{args: true},
+			wantNames: []string{"foo-bar", "FooBar"},
+		},
+		{
+			desc:      "cmd",
+			give:      `shon:"build,cmd"`,
+			want:      fieldTag{name: "build", cmd: true},
+			wantNames: []string{"build"},
+		},
+		{
+			desc:      "help",
+			give:      `shon:"foo" help:"Does things."`,
+			want:      fieldTag{name: "foo", help: "Does things."},
+			wantNames: []string{"foo"},
+		},
+		{
+			desc:      "enum",
+			give:      `shon:"level" enum:"debug info 'very loud'"`,
+			want:      fieldTag{name: "level", enum: []string{"debug", "info", "very loud"}},
+			wantNames: []string{"level"},
+		},
+		{
+			desc:      "layout with comma",
+			give:      `shon:"when,layout=Mon, 02 Jan 2006"`,
+			want:      fieldTag{name: "when", layout: "Mon, 02 Jan 2006"},
+			wantNames: []string{"when"},
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			f := reflect.StructField{Name: "FooBar", Tag: tt.give}
+			got, err := parseFieldTag(f)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.wantNames, got.names(f))
+		})
+	}
+}
+
+func TestParseFieldTag_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc    string
+		give    reflect.StructTag
+		wantErr string
+	}{
+		{
+			desc:    "unknown option",
+			give:    `shon:"foo,bar"`,
+			wantErr: `field FooBar: unknown tag option "bar"`,
+		},
+		{
+			desc:    "empty layout",
+			give:    `shon:"foo,layout="`,
+			wantErr: "field FooBar: layout must not be empty",
+		},
+		{
+			desc:    "required with value",
+			give:    `shon:"foo,required=yes"`,
+			wantErr: `field FooBar: tag option "required" does not take a value`,
+		},
+		{
+			desc:    "required with default",
+			give:    `shon:"foo,required" default:"bar"`,
+			wantErr: "field FooBar: required fields cannot have a default",
+		},
+		{
+			desc:    "remaining and passthrough",
+			give:    `shon:",remaining,passthrough"`,
+			wantErr: "field FooBar: remaining and passthrough cannot be used together",
+		},
+		{
+			desc:    "arg and args",
+			give:    `shon:",arg,args"`,
+			wantErr: "field FooBar: arg and args cannot be used together",
+		},
+		{
+			desc:    "cmd and args",
+			give:    `shon:",args,cmd"`,
+			wantErr: "field FooBar: args and cmd cannot be used together",
+		},
+		{
+			desc:    "inline arg",
+			give:    `shon:",inline,arg"`,
+			wantErr: "field FooBar: inline and arg cannot be used together",
+		},
+		{
+			desc:    "bad default",
+			give:    `default:"'foo"`,
+			wantErr: "field FooBar: bad default: unclosed '",
+		},
+		{
+			desc:    "bad enum",
+			give:    `enum:"a 'b"`,
+			wantErr: "field FooBar: bad enum: unclosed '",
+		},
+		{
+			desc:    "empty enum",
+			give:    `enum:""`,
+			wantErr: "field FooBar: enum must not be empty",
+		},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseFieldTag(reflect.StructField{Name: "FooBar", Tag: tt.give})
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
+
+func TestSplitArgs(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give string
+		want []string
+	}{
+		{desc: "empty", give: "", want: nil},
+		{desc: "spaces", give: "  \t ", want: nil},
+		{desc: "single", give: "foo", want: []string{"foo"}},
+		{desc: "multiple", give: " [ a  b ] ", want: []string{"[", "a", "b", "]"}},
+		{desc: "single quotes", give: `'a b' c`, want: []string{"a b", "c"}},
+		{desc: "single quotes literal", give: `'a\b"c'`, want: []string{`a\b"c`}},
+		{desc: "empty quotes", give: `'' ""`, want: []string{"", ""}},
+		{desc: "double quotes", give: `"a 'b' \"c\" \\ \d"`, want: []string{`a 'b' "c" \ \d`}},
+		{desc: "backslash", give: `a\ b \'c`, want: []string{"a b", "'c"}},
+		{desc: "adjacent quotes", give: `'it'\''s' x"y"z`, want: []string{"it's", "xyz"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := splitArgs(tt.give)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestSplitArgs_errors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give    string
+		wantErr string
+	}{
+		{`'foo`, "unclosed '"},
+		{`"foo`, `unclosed "`},
+		{`foo\`, `unexpected end of input after '\'`},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.give, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := splitArgs(tt.give)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package shon
+
+import (
+	"errors"
+	"reflect"
+)
+
+// Unmarshaler is implemented by types that can decode themselves
+// from a SHON value.
+//
+// [Parse] will call UnmarshalSHON for any type that implements this
+// interface, or whose pointer implements it,
+// instead of decoding it based on its kind.
+//
+// For example:
+//
+//	type Version struct{ Major, Minor int }
+//
+//	func (v *Version) UnmarshalSHON(d *shon.Decoder) error {
+//		s, err := d.Text()
+//		if err != nil {
+//			return err
+//		}
+//		_, err = fmt.Sscanf(s, "%d.%d", &v.Major, &v.Minor)
+//		return err
+//	}
+//
+// Pointers to types that implement Unmarshaler are set to nil
+// for the null value (-n) without calling UnmarshalSHON.
+type Unmarshaler interface {
+	UnmarshalSHON(*Decoder) error
+}
+
+var _unmarshalerType = reflect.TypeOf((*Unmarshaler)(nil)).Elem()
+
+// Decoder provides access to a single SHON value
+// for implementations of [Unmarshaler].
+//
+// A Decoder is valid only for the duration of the UnmarshalSHON call
+// it was passed to.
+// Composite values (arrays and objects) may be read only once.
+// Parts of the value that are not read are discarded.
+type Decoder struct {
+	ctx decodeCtx
+	v   value
+}
+
+// Kind reports the kind of value held by the Decoder.
+func (d *Decoder) Kind() Kind {
+	return Kind(d.v.t)
+}
+
+// IsNumber reports whether the value is a scalar that looks like a number.
+func (d *Decoder) IsNumber() bool {
+	return d.v.t == scalarType && d.v.num
+}
+
+// Bool returns the value of a boolean: -t or -f.
+// It returns an error if the value is not a boolean.
+func (d *Decoder) Bool() (bool, error) {
+	if d.v.t != boolType {
+		return false, d.ctx.errorf(nil, d.v, "expected bool, got %v", d.v.t)
+	}
+	return d.v.b, nil
+}
+
+// Text returns the text of a scalar or string value.
+// It returns an error if the value is not one of these.
+func (d *Decoder) Text() (string, error) {
+	switch d.v.t {
+	case scalarType, stringType:
+		return d.v.s, nil
+	default:
+		return "", d.ctx.errorf(nil, d.v, "expected text, got %v", d.v.t)
+	}
+}
+
+// Array calls fn with each item of an array value, in order.
+// It returns an error if the value is not an array,
+// or if fn returns an error.
+func (d *Decoder) Array(fn func(*Decoder) error) error {
+	if d.v.t != arrayType {
+		return d.ctx.errorf(nil, d.v, "expected array, got %v", d.v.t)
+	}
+
+	for r, idx := d.v.i.(reader), 0; r.more(); idx++ {
+		item, err := r.next()
+		if err != nil {
+			return err
+		}
+
+		if err := d.sub(d.ctx.withIndex(idx), item, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Object calls fn with each key-value pair of an object value, in order.
+// It returns an error if the value is not an object,
+// or if fn returns an error.
+func (d *Decoder) Object(fn func(key string, value *Decoder) error) error {
+	if d.v.t != objectType {
+		return d.ctx.errorf(nil, d.v, "expected object, got %v", d.v.t)
+	}
+
+	for r := d.v.i.(objectReader); r.more(); {
+		key, item, err := r.next()
+		if err != nil {
+			return err
+		}
+
+		err = d.sub(d.ctx.withKey(key), item, func(d *Decoder) error {
+			return fn(key, d)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// sub calls fn with a Decoder for v in ctx,
+// and discards whatever portion of v was left unread.
+//
+// v is discarded even if fn fails so that implementations
+// that recover from errors in fn don't leave the input half-read.
+func (d *Decoder) sub(ctx decodeCtx, v value, fn func(*Decoder) error) error {
+	err := fn(&Decoder{ctx: ctx, v: v})
+	if err != nil {
+		err = wrapUnmarshalError(ctx, nil, v, err)
+	}
+	if skipErr := skip(v); err == nil {
+		err = skipErr
+	}
+	return err
+}
+
+// Decode decodes the value into the value pointed to by v,
+// using the same rules as [Parse].
+func (d *Decoder) Decode(v any) error {
+	dst := reflect.ValueOf(v)
+	if dst.Kind() != reflect.Pointer {
+		return errors.New("must be a pointer")
+	}
+
+	dec, err := newDecoder(dst.Type().Elem())
+	if err != nil {
+		return err
+	}
+
+	// Report errors to the Unmarshaler
+	// even if AllErrors is set.
+	// The Unmarshaler will return them to the caller.
+	ctx := d.ctx.into(dst.Elem())
+	ctx.Errs = nil
+
+	res, err := dec.Decode(ctx, d.v)
+	if err != nil {
+		return err
+	}
+
+	dst.Elem().Set(res)
+	return nil
+}
+
+type unmarshalerDecoder struct {
+	t reflect.Type
+}
+
+func (d *unmarshalerDecoder) Decode(ctx decodeCtx, t value) (reflect.Value, error) {
+	v := reflect.New(d.t)
+	if ctx.Into.IsValid() {
+		v.Elem().Set(ctx.Into)
+	}
+
+	ctx.Into = reflect.Value{}
+	dec := Decoder{ctx: ctx, v: t}
+	if err := v.Interface().(Unmarshaler).UnmarshalSHON(&dec); err != nil {
+		return reflect.Value{}, wrapUnmarshalError(ctx, d.t, t, err)
+	}
+
+	if err := skip(t); err != nil {
+		return reflect.Value{}, err
+	}
+	return v.Elem(), nil
+}
+
+// wrapUnmarshalError wraps an error returned by an Unmarshaler
+// for value v into a DecodeError
+// unless it already carries position information.
+func wrapUnmarshalError(ctx decodeCtx, t reflect.Type, v value, err error) error {
+	var (
+		decodeErr *DecodeError
+		syntaxErr *SyntaxError
+	)
+	if errors.As(err, &decodeErr) || errors.As(err, &syntaxErr) {
+		return err
+	}
+	return ctx.wrapError(t, v, err)
+}
@@ -0,0 +1,224 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testVersion decodes from a "major.minor" string.
+type testVersion struct{ Major, Minor int }
+
+func (v *testVersion) UnmarshalSHON(d *Decoder) error {
+	s, err := d.Text()
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Sscanf(s, "%d.%d", &v.Major, &v.Minor)
+	return err
+}
+
+// testSelector decodes from a "key=value" string,
+// an array of such strings, or an object.
+type testSelector map[string]string
+
+func (s *testSelector) UnmarshalSHON(d *Decoder) error {
+	*s = make(testSelector)
+	add := func(d *Decoder) error {
+		text, err := d.Text()
+		if err != nil {
+			return err
+		}
+		k, v, ok := strings.Cut(text, "=")
+		if !ok {
+			return fmt.Errorf("bad selector %q", text)
+		}
+		(*s)[k] = v
+		return nil
+	}
+
+	switch d.Kind() {
+	case ArrayKind:
+		return d.Array(add)
+	case ObjectKind:
+		return d.Object(func(key string, d *Decoder) error {
+			var v string
+			if err := d.Decode(&v); err != nil {
+				return err
+			}
+			(*s)[key] = v
+			return nil
+		})
+	default:
+		return add(d)
+	}
+}
+
+// testKind records the kind of the value it was given.
+type testKind struct {
+	kind   Kind
+	number bool
+}
+
+func (k *testKind) UnmarshalSHON(d *Decoder) error {
+	k.kind = d.Kind()
+	k.number = d.IsNumber()
+	return nil
+}
+
+// testIgnore ignores its value entirely.
+type testIgnore struct{}
+
+func (*testIgnore) UnmarshalSHON(*Decoder) error { return nil }
+
+func TestUnmarshaler(t *testing.T) {
+	t.Parallel()
+
+	t.Run("scalar", func(t *testing.T) {
+		t.Parallel()
+
+		var got testVersion
+		require.NoError(t, Parse([]string{"1.2"}, &got))
+		assert.Equal(t, testVersion{Major: 1, Minor: 2}, got)
+	})
+
+	t.Run("pointer", func(t *testing.T) {
+		t.Parallel()
+
+		var got *testVersion
+		require.NoError(t, Parse([]string{"1.2"}, &got))
+		assert.Equal(t, &testVersion{Major: 1, Minor: 2}, got)
+
+		require.NoError(t, Parse([]string{"-n"}, &got))
+		assert.Nil(t, got)
+	})
+
+	t.Run("struct field", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Version  testVersion
+			Selector testSelector
+		}
+		require.NoError(t, ParseObject([]string{
+			"--version", "3.4",
+			"--selector", "[", "app=web", "tier=fe", "]",
+		}, &got))
+		assert.Equal(t, testVersion{Major: 3, Minor: 4}, got.Version)
+		assert.Equal(t, testSelector{"app": "web", "tier": "fe"}, got.Selector)
+	})
+
+	t.Run("object", func(t *testing.T) {
+		t.Parallel()
+
+		var got []testSelector
+		require.NoError(t, Parse([]string{
+			"[",
+			"[", "--app", "web", "]",
+			"a=b",
+			"]",
+		}, &got))
+		assert.Equal(t, []testSelector{{"app": "web"}, {"a": "b"}}, got)
+	})
+
+	t.Run("kinds", func(t *testing.T) {
+		t.Parallel()
+
+		var got []testKind
+		require.NoError(t, Parse([]string{
+			"[", "-n", "-t", "--", "x", "x", "42", "[]", "[--]", "]",
+		}, &got))
+		assert.Equal(t, []testKind{
+			{kind: NullKind},
+			{kind: BoolKind},
+			{kind: StringKind},
+			{kind: ScalarKind},
+			{kind: ScalarKind, number: true},
+			{kind: ArrayKind},
+			{kind: ObjectKind},
+		}, got)
+	})
+
+	t.Run("unread values are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Ignore testIgnore
+			After  string
+		}
+		require.NoError(t, ParseObject([]string{
+			"--ignore", "[", "[", "a", "]", "--", "]", "]",
+			"--after", "x",
+		}, &got))
+		assert.Equal(t, "x", got.After)
+	})
+
+	t.Run("partially read values are skipped", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Partial partialUnmarshaler
+			After   int
+		}
+		require.NoError(t, ParseObject([]string{
+			"--partial", "[", "[", "1", "2", "3", "]", "4", "]",
+			"--after", "5",
+		}, &got))
+		assert.Equal(t, 1, got.Partial.first)
+		assert.Equal(t, 5, got.After)
+	})
+
+	t.Run("error", func(t *testing.T) {
+		t.Parallel()
+
+		var got testSelector
+		err := Parse([]string{"[", "a=b", "c", "]"}, &got)
+		assert.ErrorContains(t, err, `bad selector "c"`)
+	})
+}
+
+// partialUnmarshaler reads only the first item of the first array
+// nested inside its own array.
+type partialUnmarshaler struct{ first int }
+
+func (u *partialUnmarshaler) UnmarshalSHON(d *Decoder) error {
+	errStop := errors.New("stop")
+	err := d.Array(func(d *Decoder) error {
+		return d.Array(func(d *Decoder) error {
+			if err := d.Decode(&u.first); err != nil {
+				return err
+			}
+			return errStop
+		})
+	})
+	if errors.Is(err, errStop) {
+		err = nil
+	}
+	return err
+}
+
+func TestDecoder_kindErrors(t *testing.T) {
+	t.Parallel()
+
+	d := Decoder{v: _null}
+	assert.Equal(t, NullKind, d.Kind())
+	assert.False(t, d.IsNumber())
+
+	_, err := d.Bool()
+	assert.ErrorContains(t, err, "expected bool, got null")
+
+	_, err = d.Text()
+	assert.ErrorContains(t, err, "expected text, got null")
+
+	err = d.Array(func(*Decoder) error { return nil })
+	assert.ErrorContains(t, err, "expected array, got null")
+
+	err = d.Object(func(string, *Decoder) error { return nil })
+	assert.ErrorContains(t, err, "expected object, got null")
+
+	assert.ErrorContains(t, d.Decode(42), "must be a pointer")
+}
@@ -0,0 +1,240 @@
+package shon
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrHelp is returned by [ParseObject] and friends
+// if the top-level object asks for help with '-h' or '--help'
+// in place of a key.
+// Use [Usage] to print help in response.
+//
+// Requests for help are not recognized
+// for structs that have a field named "help".
+var ErrHelp = errors.New("help requested")
+
+// Usage returns help text for the arguments accepted by v,
+// which must be a struct or a pointer to one
+// for anything other than a brief summary.
+//
+// The help text lists the keys of the struct with the SHON form
+// of their values, e.g. '-t|-f' for bools
+// and '[ <string>... ]' for slices of strings,
+// along with their default values, whether they're required,
+// and help text specified with the help:".." struct tag:
+//
+//	type Options struct {
+//		Verbose bool `shon:"verbose" help:"Print more output."`
+//	}
+//
+// The fields of nested structs are listed below their parent.
+// Positional arguments and subcommands are listed separately.
+//
+// Usage panics if v is not a type supported by [Parse].
+func Usage(v any) string {
+	t := reflect.TypeOf(v)
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == nil {
+		return ""
+	}
+
+	dec, err := newDecoder(t)
+	if err != nil {
+		panic(fmt.Sprintf("shon.Usage: %v", err))
+	}
+
+	sd, ok := dec.(*structDecoder)
+	if !ok {
+		return usageShape(dec) + "\n"
+	}
+
+	var u usageWriter
+	if err := u.command(sd); err != nil {
+		panic(fmt.Sprintf("shon.Usage: %v", err))
+	}
+	return u.String()
+}
+
+type usageWriter struct {
+	strings.Builder
+}
+
+// command writes the sections for a top-level struct.
+func (u *usageWriter) command(sd *structDecoder) error {
+	infos, err := visibleFields(sd.t)
+	if err != nil {
+		return err
+	}
+	var cmds []fieldInfo
+	for _, info := range infos {
+		if info.tag.cmd {
+			cmds = append(cmds, info)
+		}
+	}
+
+	if len(sd.args) > 0 || sd.argsRest >= 0 {
+		u.section("Arguments")
+		for _, i := range sd.args {
+			f := sd.fields[i]
+			u.entry(1, "<"+f.names[0]+">", f)
+		}
+		if sd.argsRest >= 0 {
+			f := sd.fields[sd.argsRest]
+			u.entry(1, "<"+f.names[0]+">...", f)
+		}
+	}
+
+	if slices.ContainsFunc(sd.fields, func(f structField) bool { return !f.positional }) {
+		u.section("Options")
+		u.fields(1, sd)
+	}
+
+	if len(cmds) > 0 {
+		u.section("Commands")
+		for _, info := range cmds {
+			u.line(1, info.names[0])
+			u.help(3, info.tag.help)
+		}
+	}
+	return nil
+}
+
+func (u *usageWriter) section(title string) {
+	if u.Len() > 0 {
+		u.WriteString("\n")
+	}
+	u.WriteString(title)
+	u.WriteString(":\n")
+}
+
+// fields writes entries for the fields of sd
+// that are addressed by name at depth.
+func (u *usageWriter) fields(depth int, sd *structDecoder) {
+	for _, f := range sd.fields {
+		if f.positional {
+			continue
+		}
+
+		u.entry(depth, "--"+f.names[0]+" "+usageShape(f.p), f)
+		if nested, ok := nestedStruct(f.p); ok {
+			u.fields(depth+2, nested)
+		}
+	}
+}
+
+// entry writes the synopsis for field f at depth,
+// followed by its help text.
+func (u *usageWriter) entry(depth int, synopsis string, f structField) {
+	switch {
+	case f.required:
+		synopsis += " (required)"
+	case f.dflt != nil:
+		dflt := make([]string, len(f.dflt))
+		for i, arg := range f.dflt {
+			dflt[i] = shellQuote(arg)
+		}
+		synopsis += " (default: " + strings.Join(dflt, " ") + ")"
+	}
+	u.line(depth, synopsis)
+	u.help(depth+2, f.help)
+}
+
+func (u *usageWriter) help(depth int, text string) {
+	if len(text) == 0 {
+		return
+	}
+	for _, line := range strings.Split(text, "\n") {
+		u.line(depth, line)
+	}
+}
+
+func (u *usageWriter) line(depth int, s string) {
+	u.WriteString(strings.Repeat("  ", depth))
+	u.WriteString(s)
+	u.WriteString("\n")
+}
+
+// nestedStruct returns the decoder for the struct
+// held inside values decoded by dec, if any.
+// This looks through pointers, slices, arrays, and map values.
+func nestedStruct(dec decoder) (*structDecoder, bool) {
+	for {
+		switch d := dec.(type) {
+		case *structDecoder:
+			return d, true
+		case *ptrDecoder:
+			dec = d.e
+		case *sliceDecoder:
+			dec = d.e
+		case *arrayDecoder:
+			dec = d.e
+		case *mapDecoder:
+			dec = d.v
+		default:
+			return nil, false
+		}
+	}
+}
+
+// usageShape describes the SHON form of values decoded by dec.
+func usageShape(dec decoder) string {
+	switch d := dec.(type) {
+	case *boolDecoder:
+		return "-t|-f"
+	case *intDecoder:
+		return "<" + d.t.Kind().String() + ">"
+	case *uintDecoder:
+		return "<" + d.t.Kind().String() + ">"
+	case *floatDecoder:
+		return "<" + d.t.Kind().String() + ">"
+	case *complexDecoder:
+		return "<" + d.t.Kind().String() + ">"
+	case *stringDecoder:
+		return "<string>"
+	case *durationDecoder:
+		return "<duration>"
+	case *timeDecoder:
+		if d.layout == time.RFC3339 {
+			return "<time>"
+		}
+		return "<" + d.layout + ">"
+	case *textUnmarshalerDecoder:
+		return usageTypeName(d.t)
+	case *unmarshalerDecoder:
+		return usageTypeName(d.t)
+	case *enumDecoder:
+		return strings.Join(d.values, "|")
+	case *ptrDecoder:
+		return usageShape(d.e)
+	case *sliceDecoder:
+		return "[ " + usageShape(d.e) + "... ]"
+	case *arrayDecoder:
+		return "[ " + usageShape(d.e) + "... ]"
+	case *mapDecoder:
+		key := "<key>"
+		if d.t.Key().Kind() != reflect.String {
+			key = usageShape(d.k)
+		}
+		return "[ --" + key + " " + usageShape(d.v) + "... ]"
+	case *structDecoder:
+		return "[ ... ]"
+	default:
+		return "<value>"
+	}
+}
+
+// usageTypeName names values of type t for help text
+// based on the name of the type, e.g. '<addr>' for netip.Addr.
+func usageTypeName(t reflect.Type) string {
+	if len(t.Name()) == 0 {
+		return "<value>"
+	}
+	return "<" + toKebab(t.Name()) + ">"
+}
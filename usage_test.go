@@ -0,0 +1,225 @@
+package shon
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsage(t *testing.T) {
+	t.Parallel()
+
+	type server struct {
+		Host string `shon:"host,required" help:"Host name or IP address."`
+		Port uint16 `shon:"port" default:"80"`
+	}
+
+	type options struct {
+		Verbose bool              `shon:"verbose" help:"Print more output."`
+		Name    string            `shon:"name" default:"'John Doe'"`
+		Level   string            `shon:"level" enum:"debug info"`
+		Timeout time.Duration     `shon:"timeout" default:"5s" help:"How long to wait.\nUse 0 to wait forever."`
+		Tags    []string          `shon:"tags"`
+		Labels  map[string]string `shon:"labels"`
+		Ports   map[int]bool      `shon:"ports"`
+		Addr    netip.Addr        `shon:"addr"`
+		Date    time.Time         `shon:"date,layout=2006-01-02"`
+		Ratio   *float64          `shon:"ratio"`
+		Any     any               `shon:"any"`
+		Server  server            `shon:"server" help:"Server to connect to."`
+		Mirrors []server          `shon:"mirrors"`
+		Extra   map[string]any    `shon:",remaining"`
+		Ignored string            `shon:"-"`
+	}
+
+	want := `Options:
+  --verbose -t|-f
+      Print more output.
+  --name <string> (default: 'John Doe')
+  --level debug|info
+  --timeout <duration> (default: 5s)
+      How long to wait.
+      Use 0 to wait forever.
+  --tags [ <string>... ]
+  --labels [ --<key> <string>... ]
+  --ports [ --<int> -t|-f... ]
+  --addr <addr>
+  --date <2006-01-02>
+  --ratio <float64>
+  --any <value>
+  --server [ ... ]
+      Server to connect to.
+      --host <string> (required)
+          Host name or IP address.
+      --port <uint16> (default: 80)
+  --mirrors [ [ ... ]... ]
+      --host <string> (required)
+          Host name or IP address.
+      --port <uint16> (default: 80)
+`
+	assert.Equal(t, want, Usage(options{}))
+	assert.Equal(t, want, Usage(&options{}), "pointer")
+	assert.Equal(t, want, Usage((*options)(nil)), "nil pointer")
+}
+
+func TestUsage_positional(t *testing.T) {
+	t.Parallel()
+
+	type copyOptions struct {
+		Force   bool     `shon:"force"`
+		Dest    string   `shon:"dest,arg,required" help:"Destination directory."`
+		Sources []string `shon:"sources,args"`
+	}
+
+	assert.Equal(t, `Arguments:
+  <dest> (required)
+      Destination directory.
+  <sources>...
+
+Options:
+  --force -t|-f
+`, Usage(copyOptions{}))
+}
+
+func TestUsage_commands(t *testing.T) {
+	t.Parallel()
+
+	type root struct {
+		Verbose bool `shon:"verbose"`
+		Build   *struct {
+			Target string `shon:"target"`
+		} `shon:"build,cmd" help:"Build the project."`
+		Test *struct{} `shon:"test,cmd"`
+	}
+
+	assert.Equal(t, `Options:
+  --verbose -t|-f
+
+Commands:
+  build
+      Build the project.
+  test
+`, Usage(root{}))
+
+	assert.Equal(t, "Options:\n  --target <string>\n", Usage(root{}.Build))
+}
+
+func TestUsage_notStruct(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		desc string
+		give any
+		want string
+	}{
+		{"nil", nil, ""},
+		{"int", 42, "<int>\n"},
+		{"slice", []bool{}, "[ -t|-f... ]\n"},
+		{"array", [2]complex64{}, "[ <complex64>... ]\n"},
+		{"map", map[string][]int{}, "[ --<key> [ <int>... ]... ]\n"},
+		{"empty struct", struct{}{}, ""},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, Usage(tt.give))
+		})
+	}
+}
+
+func TestUsage_unsupported(t *testing.T) {
+	t.Parallel()
+
+	assert.PanicsWithValue(t, "shon.Usage: unsupported type chan int", func() {
+		Usage(make(chan int))
+	})
+}
+
+func TestParseObject_help(t *testing.T) {
+	t.Parallel()
+
+	type options struct {
+		Verbose bool     `shon:"verbose"`
+		Name    string   `shon:"name"`
+		Files   []string `shon:"files,args"`
+	}
+
+	tests := []struct {
+		desc     string
+		give     []string
+		opts     []ParseOption
+		wantHelp bool
+	}{
+		{desc: "short", give: []string{"-h"}, wantHelp: true},
+		{desc: "long", give: []string{"--help"}, wantHelp: true},
+		{desc: "after keys", give: []string{"--verbose", "-t", "a", "-h"}, wantHelp: true},
+		{desc: "all errors", give: []string{"--verbose", "x", "-h"}, opts: []ParseOption{AllErrors(true)}, wantHelp: true},
+		{desc: "escaped value", give: []string{"--name", "--", "-h"}},
+		{desc: "after terminator", give: []string{"--", "-h", "--help"}},
+	}
+
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.desc, func(t *testing.T) {
+			t.Parallel()
+
+			var got options
+			err := ParseObject(tt.give, &got, tt.opts...)
+			if tt.wantHelp {
+				assert.Equal(t, ErrHelp, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+
+	t.Run("nested", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Child options `shon:"child"`
+		}
+		err := ParseObject([]string{"--child", "[", "--help", "-t", "]"}, &got)
+		assert.EqualError(t, err, `argument 2 (.child.help): unknown field "help"`)
+	})
+
+	t.Run("help field", func(t *testing.T) {
+		t.Parallel()
+
+		var got struct {
+			Help bool `shon:"help"`
+		}
+		require.NoError(t, ParseObject([]string{"--help", "-t"}, &got))
+		assert.True(t, got.Help)
+
+		err := ParseObject([]string{"-h"}, &got)
+		assert.EqualError(t, err, `argument 0: expected object key, got "-h"`)
+	})
+
+	t.Run("map", func(t *testing.T) {
+		t.Parallel()
+
+		var got map[string]any
+		require.NoError(t, ParseObject([]string{"--help", "-t"}, &got))
+		assert.Equal(t, map[string]any{"help": true}, got)
+	})
+
+	t.Run("prefix", func(t *testing.T) {
+		t.Parallel()
+
+		var got options
+		_, err := ParseObjectPrefix([]string{"--verbose", "-t", "-h"}, &got)
+		assert.Equal(t, ErrHelp, err)
+
+		var m map[string]any
+		rest, err := ParseObjectPrefix([]string{"-h"}, &m)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"-h"}, rest)
+	})
+}
@@ -4,6 +4,8 @@ import (
 	"fmt"
 )
 
+// valueType is the internal counterpart of Kind.
+// The two must have the same ordinals.
 type valueType int
 
 const (
@@ -37,6 +39,38 @@ func (t valueType) String() string {
 	}
 }
 
+// Kind specifies the kind of a SHON value.
+type Kind int
+
+const (
+	// NullKind is the kind of the null value: -n.
+	NullKind Kind = iota + 1
+
+	// BoolKind is the kind of boolean values: -t and -f.
+	BoolKind
+
+	// StringKind is the kind of values that are explicitly strings,
+	// e.g. those preceded by '--'.
+	StringKind
+
+	// ScalarKind is the kind of bare values
+	// that may be strings or numbers depending on the target type.
+	ScalarKind
+
+	// ArrayKind is the kind of arrays: [], [ ... ].
+	ArrayKind
+
+	// ObjectKind is the kind of objects: [--], [ --k v ... ].
+	ObjectKind
+)
+
+func (k Kind) String() string {
+	if k >= NullKind && k <= ObjectKind {
+		return valueType(k).String()
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
 type reader interface {
 	more() bool
 	next() (value, error)
@@ -47,6 +81,38 @@ type objectReader interface {
 	next() (string, value, error)
 }
 
+// positionalReader is an objectReader
+// that may interleave positional arguments with its keys.
+type positionalReader interface {
+	objectReader
+
+	// allowPositionals allows positional arguments in the object.
+	// This has no effect if the object cannot hold them.
+	allowPositionals()
+
+	// positional reports whether the item found by more()
+	// is a positional argument.
+	// If so, it must be read with nextPositional instead of next.
+	positional() bool
+
+	// nextPositional reads a positional argument.
+	nextPositional() (value, error)
+}
+
+// helpReader is an objectReader
+// that can recognize requests for help among its keys.
+type helpReader interface {
+	objectReader
+
+	// detectHelp enables recognizing requests for help in the object.
+	// This has no effect if the object cannot hold them.
+	detectHelp()
+
+	// help reports whether the item found by more()
+	// is a request for help: '-h' or '--help'.
+	help() bool
+}
+
 type value struct {
 	t valueType
 	b bool   // set if boolType
@@ -54,6 +120,10 @@ type value struct {
 	i any    // reader if arrayType, objectReader if objectType
 
 	num bool // whether numeric if scalarType
+	esc bool // whether preceded by '--' if stringType
+
+	pos  int // index of the argument that started this value
+	kpos int // index of the key argument if this value is in an object
 }
 
 var (
@@ -76,3 +146,32 @@ func arrayValue(r reader) value {
 func objectValue(r objectReader) value {
 	return value{t: objectType, i: r}
 }
+
+// skip consumes the remainder of v from the input, discarding it.
+// It is a no-op for values that have already been read fully.
+func skip(v value) error {
+	switch v.t {
+	case arrayType:
+		for r := v.i.(reader); r.more(); {
+			item, err := r.next()
+			if err != nil {
+				return err
+			}
+			if err := skip(item); err != nil {
+				return err
+			}
+		}
+
+	case objectType:
+		for r := v.i.(objectReader); r.more(); {
+			_, item, err := r.next()
+			if err != nil {
+				return err
+			}
+			if err := skip(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
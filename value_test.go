@@ -33,3 +33,41 @@ func TestValueType_String(t *testing.T) {
 		})
 	}
 }
+
+func TestKind_String(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		give Kind
+		want string
+	}{
+		{NullKind, "null"},
+		{BoolKind, "bool"},
+		{StringKind, "string"},
+		{ScalarKind, "scalar"},
+		{ArrayKind, "array"},
+		{ObjectKind, "object"},
+		{Kind(0), "Kind(0)"},
+		{Kind(42), "Kind(42)"},
+	}
+
+	for i, tt := range tests {
+		tt := tt
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, tt.give.String())
+		})
+	}
+}
+
+func TestKind_matchesValueType(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, int(nullType), int(NullKind))
+	assert.Equal(t, int(boolType), int(BoolKind))
+	assert.Equal(t, int(stringType), int(StringKind))
+	assert.Equal(t, int(scalarType), int(ScalarKind))
+	assert.Equal(t, int(arrayType), int(ArrayKind))
+	assert.Equal(t, int(objectType), int(ObjectKind))
+}